@@ -0,0 +1,103 @@
+package block
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount adalah nilai koin dalam satuan terkecil (base unit). Satu koin sama
+// dengan AMOUNT_UNIT base unit, sehingga nilai seperti 0.1 tersimpan persis.
+type Amount uint64
+
+const (
+	AMOUNT_DECIMALS        = 8
+	AMOUNT_UNIT     Amount = 100000000
+	MAX_AMOUNT      Amount = math.MaxUint64
+)
+
+// ParseAmount mengubah string desimal seperti "12.5" menjadi Amount. Nilai
+// negatif, lebih dari AMOUNT_DECIMALS digit desimal, atau yang melebihi
+// MAX_AMOUNT ditolak.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return 0, ErrNegativeAmount
+	}
+	s = strings.TrimPrefix(s, "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, ErrInvalidAmount
+	}
+	if len(frac) > AMOUNT_DECIMALS {
+		return 0, ErrInvalidAmount
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, ErrInvalidAmount
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	frac += strings.Repeat("0", AMOUNT_DECIMALS-len(frac))
+
+	w, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, ErrAmountOverflow
+	}
+	f, _ := strconv.ParseUint(frac, 10, 64)
+	if w > uint64(MAX_AMOUNT-Amount(f))/uint64(AMOUNT_UNIT) {
+		return 0, ErrAmountOverflow
+	}
+	return Amount(w)*AMOUNT_UNIT + Amount(f), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String memformat Amount sebagai desimal tanpa nol di belakang koma,
+// misalnya 150000000 menjadi "1.5".
+func (a Amount) String() string {
+	whole := uint64(a / AMOUNT_UNIT)
+	frac := uint64(a % AMOUNT_UNIT)
+	if frac == 0 {
+		return strconv.FormatUint(whole, 10)
+	}
+	fracStr := strconv.FormatUint(frac, 10)
+	fracStr = strings.Repeat("0", AMOUNT_DECIMALS-len(fracStr)) + fracStr
+	return strconv.FormatUint(whole, 10) + "." + strings.TrimRight(fracStr, "0")
+}
+
+// Add menjumlahkan dua Amount dan mengembalikan ErrAmountOverflow bila
+// hasilnya melebihi MAX_AMOUNT.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a > MAX_AMOUNT-b {
+		return 0, ErrAmountOverflow
+	}
+	return a + b, nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("amount must be a decimal string")
+	}
+	v, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
@@ -0,0 +1,101 @@
+package block
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  error
+	}{
+		{"0", 0, nil},
+		{"1", AMOUNT_UNIT, nil},
+		{"12.5", 12*AMOUNT_UNIT + AMOUNT_UNIT/2, nil},
+		{"0.1", 10000000, nil},
+		{".5", 50000000, nil},
+		{"3.", 3 * AMOUNT_UNIT, nil},
+		{"+2", 2 * AMOUNT_UNIT, nil},
+		{" 7 ", 7 * AMOUNT_UNIT, nil},
+		{"0.00000001", 1, nil},
+		{"184467440737.09551615", MAX_AMOUNT, nil},
+		{"184467440737.09551616", 0, ErrAmountOverflow},
+		{"184467440738", 0, ErrAmountOverflow},
+		{"99999999999999999999999", 0, ErrAmountOverflow},
+		{"0.000000001", 0, ErrInvalidAmount},
+		{"-1", 0, ErrNegativeAmount},
+		{"", 0, ErrInvalidAmount},
+		{".", 0, ErrInvalidAmount},
+		{"1e5", 0, ErrInvalidAmount},
+		{"1.2.3", 0, ErrInvalidAmount},
+		{"abc", 0, ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAmount(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseAmount(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAmount(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{0, "0"},
+		{1, "0.00000001"},
+		{AMOUNT_UNIT, "1"},
+		{150000000, "1.5"},
+		{12*AMOUNT_UNIT + 10000000, "12.1"},
+		{MAX_AMOUNT, "184467440737.09551615"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", uint64(tt.in), got, tt.want)
+		}
+		if parsed, err := ParseAmount(tt.want); err != nil || parsed != tt.in {
+			t.Errorf("ParseAmount(%q) = %d, %v, want %d", tt.want, parsed, err, tt.in)
+		}
+	}
+}
+
+func TestAmountAdd(t *testing.T) {
+	if sum, err := Amount(2).Add(3); err != nil || sum != 5 {
+		t.Errorf("2 + 3 = %d, %v", sum, err)
+	}
+	if sum, err := (MAX_AMOUNT - 1).Add(1); err != nil || sum != MAX_AMOUNT {
+		t.Errorf("MAX-1 + 1 = %d, %v", sum, err)
+	}
+	if _, err := MAX_AMOUNT.Add(1); !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("MAX + 1 error = %v, want %v", err, ErrAmountOverflow)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	data, err := json.Marshal(Amount(150000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"1.5"` {
+		t.Errorf("Marshal = %s, want \"1.5\"", data)
+	}
+	var a Amount
+	if err := json.Unmarshal(data, &a); err != nil || a != 150000000 {
+		t.Errorf("Unmarshal = %d, %v", a, err)
+	}
+
+	for _, bad := range []string{`1.5`, `"-1"`, `"1.000000001"`} {
+		if err := json.Unmarshal([]byte(bad), &a); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want error", bad)
+		}
+	}
+}
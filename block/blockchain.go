@@ -2,10 +2,10 @@ package block
 
 import (
 	"bytes"
-	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"learn-blockchain/utils"
 	"log"
@@ -16,10 +16,10 @@ import (
 )
 
 const (
-	MINING_DIFFICULTY = 3
-	MINING_SENDER     = "THE BLOCKCHAIN"
-	MINING_REWARD     = 1.0
-	MINING_TIMER_SEC  = 20
+	MINING_SENDER = "THE BLOCKCHAIN"
+
+	// GENESIS_TIMESTAMP adalah 2024-01-01T00:00:00Z dalam nanodetik
+	GENESIS_TIMESTAMP = 1704067200 * int64(time.Second)
 
 	BLOCKCHAIN_PORT_RANGE_START       = 5000
 	BLOCKCHAIN_PORT_RANGE_END         = 5003
@@ -32,14 +32,18 @@ type Block struct {
 	timestamp    int64
 	nonce        int
 	previousHash [32]byte
+	merkleRoot   [32]byte
+	difficulty   int
 	transactions []*Transaction
 }
 
-func CreateNewBlock(nonce int, previosHash [32]byte, transactions []*Transaction) *Block {
+func CreateNewBlock(nonce int, previosHash [32]byte, difficulty int, transactions []*Transaction) *Block {
 	block := new(Block)
 	block.timestamp = time.Now().UnixNano()
 	block.nonce = nonce
 	block.previousHash = previosHash
+	block.merkleRoot = MerkleRoot(transactions)
+	block.difficulty = difficulty
 	block.transactions = transactions
 	return block
 }
@@ -52,6 +56,18 @@ func (b *Block) Nonce() int {
 	return b.nonce
 }
 
+func (b *Block) MerkleRoot() [32]byte {
+	return b.merkleRoot
+}
+
+func (b *Block) Difficulty() int {
+	return b.difficulty
+}
+
+func (b *Block) Timestamp() int64 {
+	return b.timestamp
+}
+
 func (b *Block) Transactions() []*Transaction {
 	return b.transactions
 }
@@ -60,16 +76,18 @@ func (block *Block) Print() {
 	fmt.Printf("timestamp       %d\n", block.timestamp)
 	fmt.Printf("nonce           %d\n", block.nonce)
 	fmt.Printf("previous_hash   %x\n", block.previousHash)
+	fmt.Printf("merkle_root     %x\n", block.merkleRoot)
+	fmt.Printf("difficulty      %d\n", block.difficulty)
 
 	for _, t := range block.transactions {
 		t.Print()
 	}
 }
 
+// Hash hanya meng-hash header block. Transaksi sudah terwakili oleh
+// merkle_root, jadi biaya hashing tidak bergantung pada jumlah transaksi.
 func (block *Block) Hash() [32]byte {
-	m, _ := json.Marshal(block)
-	// fmt.Println(m)
-	return sha256.Sum256([]byte(m))
+	return sha256.Sum256(block.HeaderBytes())
 }
 
 func (block *Block) MarshalJSON() ([]byte, error) {
@@ -77,61 +95,142 @@ func (block *Block) MarshalJSON() ([]byte, error) {
 		Timestamp    int64          `json:"timestamp"`
 		Nonce        int            `json:"nonce"`
 		PreviosHash  string         `json:"previous_hash"`
+		MerkleRoot   string         `json:"merkle_root"`
+		Difficulty   int            `json:"difficulty"`
 		Transactions []*Transaction `json:"transactions"`
 	}{
 		Timestamp:    block.timestamp,
 		Nonce:        block.nonce,
 		PreviosHash:  fmt.Sprintf("%x", block.previousHash),
+		MerkleRoot:   fmt.Sprintf("%x", block.merkleRoot),
+		Difficulty:   block.difficulty,
 		Transactions: block.transactions,
 	})
 }
 
 func (b *Block) UnmarshalJSON(data []byte) error {
-	var previousHash string
+	var previousHash, merkleRoot string
 	v := &struct {
 		Timestamp    *int64          `json:"timestamp"`
 		Nonce        *int            `json:"nonce"`
 		PreviousHash *string         `json:"previous_hash"`
+		MerkleRoot   *string         `json:"merkle_root"`
+		Difficulty   *int            `json:"difficulty"`
 		Transactions *[]*Transaction `json:"transactions"`
 	}{
 		Timestamp:    &b.timestamp,
 		Nonce:        &b.nonce,
 		PreviousHash: &previousHash,
+		MerkleRoot:   &merkleRoot,
+		Difficulty:   &b.difficulty,
 		Transactions: &b.transactions,
 	}
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
-	ph, _ := hex.DecodeString(*v.PreviousHash)
-	copy(b.previousHash[:], ph[:32])
+	// Block dari peer tidak dipercaya: hash harus tepat 32 byte dan setiap
+	// entri transaksi harus ada
+	ph, err := hex.DecodeString(previousHash)
+	if err != nil || len(ph) != 32 {
+		return errors.New("invalid previous hash")
+	}
+	copy(b.previousHash[:], ph)
+	mr, err := hex.DecodeString(merkleRoot)
+	if err != nil || len(mr) != 32 {
+		return errors.New("invalid merkle root")
+	}
+	copy(b.merkleRoot[:], mr)
+	for _, t := range b.transactions {
+		if t == nil {
+			return errors.New("invalid block transaction")
+		}
+	}
 	return nil
 }
 
 type Blockchain struct {
-	transactionPool   []*Transaction
+	mempool           *Mempool
 	chain             []*Block
 	blockchainAddress string
 	port              uint16
-	mux               sync.Mutex
+	mux               sync.RWMutex // melindungi chain; dikunci sebelum Mempool.mux
+	storage           Storage
+	coinbaseMaturity  int
 
 	neighbors    []string
 	muxNeighbors sync.Mutex
+
+	// changed ditutup setiap kali tip chain atau mempool berubah
+	changed    chan struct{}
+	muxChanged sync.Mutex
+	stopMiner  func()
+	muxMiner   sync.Mutex
 }
 
-func NewBlockchain(blockchainAddress string, port uint16) *Blockchain {
-	block := &Block{}
+// NewBlockchain memuat chain dari storage (jika ada) dan membuat genesis block
+// bila storage masih kosong. storage boleh nil untuk chain yang hanya di memori.
+// coinbaseMaturity adalah jumlah konfirmasi sebelum reward mining bisa
+// dibelanjakan (lihat COINBASE_MATURITY).
+func NewBlockchain(blockchainAddress string, port uint16, storage Storage, coinbaseMaturity int) *Blockchain {
 	blockchain := new(Blockchain)
 	blockchain.blockchainAddress = blockchainAddress
 	blockchain.port = port
-	blockchain.CreateBlock(0, block.Hash())
+	blockchain.storage = storage
+	blockchain.coinbaseMaturity = coinbaseMaturity
+	blockchain.changed = make(chan struct{})
+	blockchain.mempool = NewMempool(chainState{blockchain})
+	blockchain.mempool.onChange = blockchain.notifyChange
+
+	if storage != nil {
+		chain, err := storage.Load()
+		if err != nil {
+			log.Printf("ERROR: Failed to load chain from storage: %v", err)
+			return nil
+		}
+		if len(chain) > 0 {
+			if !blockchain.ValidChain(chain) {
+				log.Printf("ERROR: Stored chain with length %d is invalid", len(chain))
+				return nil
+			}
+			blockchain.chain = chain
+			return blockchain
+		}
+	}
+
+	blockchain.mux.Lock()
+	defer blockchain.mux.Unlock()
+	if err := blockchain.appendBlock(GenesisBlock()); err != nil {
+		log.Printf("ERROR: Failed to create genesis block: %v", err)
+		return nil
+	}
 
 	return blockchain
 }
 
+// GenesisBlock mengembalikan genesis block yang sama di setiap node. Chain
+// yang dimulai dari block lain ditolak ValidChain, sehingga peer tidak bisa
+// mengirim genesis dengan difficulty atau coinbase buatan sendiri.
+func GenesisBlock() *Block {
+	return &Block{
+		timestamp:    GENESIS_TIMESTAMP,
+		previousHash: (&Block{}).Hash(),
+		merkleRoot:   MerkleRoot(nil),
+		difficulty:   INITIAL_DIFFICULTY,
+	}
+}
+
+// Chain mengembalikan chain saat ini. Block tidak pernah diubah setelah
+// masuk chain, jadi hasilnya aman dibaca tanpa lock.
 func (bc *Blockchain) Chain() []*Block {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
 	return bc.chain
 }
 
+func (bc *Blockchain) BlockchainAddress() string {
+	return bc.blockchainAddress
+}
+
 func (bc *Blockchain) Run() {
 	if bc == nil {
 		log.Fatal("Blockchain is nil, cannot run!")
@@ -160,19 +259,31 @@ func (bc *Blockchain) StartSyncNeighbors() {
 	_ = time.AfterFunc(time.Second*BLOCKCHAIN_NEIGHBOR_SYNC_TIME_SEC, bc.StartSyncNeighbors)
 }
 
+func (bc *Blockchain) Mempool() *Mempool {
+	return bc.mempool
+}
+
+// RestoreMempool memuat transaksi pending dari journal setelah restart dan
+// mencatat perubahan pool berikutnya ke journal itu.
+func (bc *Blockchain) RestoreMempool(journal *MempoolJournal) error {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+	return bc.mempool.Restore(journal)
+}
+
 func (bc *Blockchain) TransactionPool() []*Transaction {
-	return bc.transactionPool
+	return bc.mempool.Transactions()
 }
 
 func (bc *Blockchain) ClearTransactionPool() {
-	bc.transactionPool = bc.transactionPool[:0]
+	bc.mempool.Clear()
 }
 
 func (bc *Blockchain) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
 		Blocks []*Block `json:"chain"`
 	}{
-		Blocks: bc.chain,
+		Blocks: bc.Chain(),
 	})
 }
 
@@ -188,27 +299,40 @@ func (bc *Blockchain) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func (blockchain *Blockchain) CreateBlock(nonce int, previosHash [32]byte) *Block {
-	block := CreateNewBlock(nonce, previosHash, blockchain.transactionPool)
-	blockchain.chain = append(blockchain.chain, block)
-	blockchain.transactionPool = []*Transaction{}
-	for _, n := range blockchain.neighbors {
-		endpoint := fmt.Sprintf("http://%s/transactions", n)
-		client := &http.Client{}
-		req, _ := http.NewRequest("DELETE", endpoint, nil)
-		resp, _ := client.Do(req)
-		log.Printf("%v", resp)
+func (blockchain *Blockchain) CreateBlock(nonce int, previosHash [32]byte) (*Block, error) {
+	blockchain.mux.Lock()
+	defer blockchain.mux.Unlock()
+	block := CreateNewBlock(nonce, previosHash, NextDifficulty(blockchain.chain), blockchain.TransactionPool())
+	if err := blockchain.appendBlock(block); err != nil {
+		return nil, err
 	}
-
-	return block
+	return block, nil
+}
+
+// appendBlock menyimpan block yang sudah jadi ke storage, lalu menambahkannya
+// ke ujung chain dan mengeluarkan transaksi yang masuk block dari pool.
+// Transaksi yang tidak muat tetap di pool untuk block berikutnya. Bila
+// storage gagal, chain di memori tidak berubah supaya tetap sama dengan isi
+// disk. Pemanggil memegang blockchain.mux.
+func (blockchain *Blockchain) appendBlock(block *Block) error {
+	if blockchain.storage != nil {
+		if err := blockchain.storage.Append(block); err != nil {
+			return fmt.Errorf("persist block: %w", err)
+		}
+	}
+	blockchain.chain = append(blockchain.chain, block)
+	blockchain.mempool.Remove(block.transactions)
+	blockchain.notifyChange()
+	return nil
 }
 
 func (blockchain *Blockchain) LastBlock() *Block {
-	return blockchain.chain[len(blockchain.chain)-1]
+	chain := blockchain.Chain()
+	return chain[len(chain)-1]
 }
 
 func (blockchain *Blockchain) Print() {
-	for i, block := range blockchain.chain {
+	for i, block := range blockchain.Chain() {
 		fmt.Printf("%s Chain %d %s\n", strings.Repeat("=", 25), i, strings.Repeat("=", 25))
 		block.Print()
 	}
@@ -216,191 +340,309 @@ func (blockchain *Blockchain) Print() {
 	fmt.Printf("%s\n", strings.Repeat("*", 60))
 }
 
-func (bc *Blockchain) CreateTransaction(sender string, recipient string, value float32,
-	senderPublicKey *ecdsa.PublicKey, s *utils.Signature) bool {
-	isTransacted := bc.AddTransaction(sender, recipient, value, senderPublicKey, s)
-
-	if isTransacted {
-		log.Printf("Neighbors before syncing transaction: %v", bc.neighbors)
-		for _, n := range bc.neighbors {
-			publicKeyStr := fmt.Sprintf("%064x%064x", senderPublicKey.X.Bytes(),
-				senderPublicKey.Y.Bytes())
-			signatureStr := s.String()
-			bt := &TransactionRequest{
-				&sender, &recipient, &publicKeyStr, &value, &signatureStr}
-			m, _ := json.Marshal(bt)
-			buf := bytes.NewBuffer(m)
-			endpoint := fmt.Sprintf("http://%s/transactions", n)
-			client := &http.Client{}
-			req, _ := http.NewRequest("PUT", endpoint, buf)
-			resp, _ := client.Do(req)
-			log.Printf("%v", resp)
+func (bc *Blockchain) CreateTransaction(sender string, recipient string, value Amount, fee Amount, nonce uint64,
+	senderPublicKey utils.Verifier, s []byte) error {
+	if err := bc.AddTransaction(sender, recipient, value, fee, nonce, senderPublicKey, s); err != nil {
+		return err
+	}
+
+	log.Printf("Neighbors before syncing transaction: %v", bc.neighbors)
+	for _, n := range bc.neighbors {
+		scheme := string(senderPublicKey.Scheme())
+		publicKeyStr := utils.PublicKeyHex(senderPublicKey)
+		signatureStr := hex.EncodeToString(s)
+		bt := &TransactionRequest{
+			SenderBlockchainAddress:    &sender,
+			RecipientBlockchainAddress: &recipient,
+			Scheme:                     &scheme,
+			SenderPublicKey:            &publicKeyStr,
+			Value:                      &value,
+			Fee:                        &fee,
+			Nonce:                      &nonce,
+			Signature:                  &signatureStr,
 		}
+		m, _ := json.Marshal(bt)
+		buf := bytes.NewBuffer(m)
+		endpoint := fmt.Sprintf("http://%s/transactions", n)
+		client := &http.Client{}
+		req, _ := http.NewRequest("PUT", endpoint, buf)
+		resp, _ := client.Do(req)
+		log.Printf("%v", resp)
 	}
 
-	return isTransacted
+	return nil
 }
 
-func (bc *Blockchain) AddTransaction(sender string, recipient string, value float32, senderPublicKey *ecdsa.PublicKey, s *utils.Signature) bool {
-	t := NewTransaction(sender, recipient, value)
+func (bc *Blockchain) AddTransaction(sender string, recipient string, value Amount, fee Amount, nonce uint64,
+	senderPublicKey utils.Verifier, s []byte) error {
+	t := NewUnsignedTransaction(senderPublicKey, sender, recipient, value, fee, nonce)
+	t.signature = s
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+	return bc.mempool.Add(t)
+}
 
-	if sender == MINING_SENDER {
-		bc.transactionPool = append(bc.transactionPool, t)
-		return true
+// VerifyTransaction memastikan transaksi ditandatangani oleh pemilik
+// sender_blockchain_address, yaitu address sender memang diturunkan dari
+// public key yang dipakai untuk verifikasi signature.
+func (bc *Blockchain) VerifyTransaction(t *Transaction) error {
+	if t.senderPublicKey == nil || t.signature == nil {
+		return ErrInvalidSignature
 	}
-
-	if bc.VerifyTransactionSignature(senderPublicKey, s, t) {
-		/*
-			if bc.CalculateTotalAmount(sender) < value {
-				log.Println("ERROR: Not enough balance in a wallet")
-				return false
-			}
-		*/
-
-		bc.transactionPool = append(bc.transactionPool, t)
-		return true
-	} else {
-		log.Print("Error : Verify transaction")
+	if utils.BlockchainAddress(t.senderPublicKey) != t.senderBlockchainAddress {
+		return ErrAddressMismatch
 	}
+	h := t.SigningHash()
+	if err := t.senderPublicKey.Verify(h[:], t.signature); err != nil {
+		if errors.Is(err, utils.ErrMalleableSignature) {
+			return ErrHighSSignature
+		}
+		return ErrInvalidSignature
+	}
+	return nil
+}
 
-	return false
+// ValidProof memastikan hash header block (termasuk timestamp) memiliki
+// paling sedikit b.difficulty bit nol di depan.
+func (bc *Blockchain) ValidProof(b *Block) bool {
+	return leadingZeroBits(b.Hash()) >= b.difficulty
 }
 
-func (bc *Blockchain) VerifyTransactionSignature(
-	senderPublicKey *ecdsa.PublicKey, s *utils.Signature, t *Transaction) bool {
-	m, _ := json.Marshal(t)
-	h := sha256.Sum256([]byte(m))
-	return ecdsa.Verify(senderPublicKey, h[:], s.R, s.S)
+func (bc *Blockchain) CalculateTotalAmount(blockchainAddress string) Amount {
+	return totalAmount(bc.Chain(), blockchainAddress)
 }
 
-func (bc *Blockchain) CopyTransactionPool() []*Transaction {
-	transactions := make([]*Transaction, len(bc.transactionPool))
-	for _, t := range bc.transactionPool {
-		transactions = append(transactions,
-			NewTransaction(t.senderBlockchainAddress,
-				t.recipientBlockchainAddress,
-				t.value))
+func totalAmount(chain []*Block, blockchainAddress string) Amount {
+	var received, sent Amount = 0, 0
+	for _, b := range chain {
+		for _, t := range b.transactions {
+			if blockchainAddress == t.recipientBlockchainAddress {
+				received += t.value
+			}
+
+			if blockchainAddress == t.senderBlockchainAddress {
+				sent += t.value + t.fee
+			}
+		}
+	}
+	// Chain yang valid tidak pernah membuat saldo negatif (lihat validBalances)
+	if sent > received {
+		return 0
 	}
-	return transactions
+	return received - sent
 }
 
-func (bc *Blockchain) ValidProof(nonce int, previousHash [32]byte, transactions []*Transaction, difficulty int) bool {
-	zeros := strings.Repeat("0", difficulty)
-	guessBlock := Block{0, nonce, previousHash, transactions}
-	guessHashStr := fmt.Sprintf("%x", guessBlock.Hash())
-	return guessHashStr[:difficulty] == zeros
+// ConfirmedNonce adalah jumlah transaksi dari sender yang sudah masuk chain,
+// sekaligus nonce yang harus dipakai transaksi berikutnya bila pool kosong.
+func (bc *Blockchain) ConfirmedNonce(blockchainAddress string) uint64 {
+	return confirmedNonce(bc.Chain(), blockchainAddress)
 }
 
-func (bc *Blockchain) ProofOfWork() int {
-	transactions := bc.CopyTransactionPool()
-	previousHash := bc.LastBlock().Hash()
-	nonce := 0
-	for !bc.ValidProof(nonce, previousHash, transactions, MINING_DIFFICULTY) {
-		nonce += 1
+func confirmedNonce(chain []*Block, blockchainAddress string) uint64 {
+	var nonce uint64 = 0
+	for _, b := range chain {
+		for _, t := range b.transactions {
+			if blockchainAddress == t.senderBlockchainAddress {
+				nonce += 1
+			}
+		}
 	}
 	return nonce
 }
 
-func (bc *Blockchain) Mining() bool {
-	bc.mux.Lock()
-	defer bc.mux.Unlock()
+func (bc *Blockchain) NextNonce(blockchainAddress string) uint64 {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+	return confirmedNonce(bc.chain, blockchainAddress) + uint64(bc.mempool.PendingCount(blockchainAddress))
+}
 
-	// if len(bc.transactionPool) == 0 {
-	// 	return false
-	// }
+// validNonces memastikan nonce setiap sender di chain berurutan mulai dari 0,
+// sehingga transaksi yang sama tidak bisa diputar ulang.
+func (bc *Blockchain) validNonces(chain []*Block) bool {
+	nonces := make(map[string]uint64)
+	for i, b := range chain {
+		for _, t := range b.transactions {
+			if t.senderBlockchainAddress == MINING_SENDER {
+				continue
+			}
+			if t.nonce != nonces[t.senderBlockchainAddress] {
+				log.Printf("Chain invalid: %s uses nonce %d at block %d, expected %d",
+					t.senderBlockchainAddress, t.nonce, i, nonces[t.senderBlockchainAddress])
+				return false
+			}
+			nonces[t.senderBlockchainAddress] += 1
+		}
+	}
+	return true
+}
 
-	bc.AddTransaction(MINING_SENDER, bc.blockchainAddress, MINING_REWARD, nil, nil)
-	nonce := bc.ProofOfWork()
-	previousHash := bc.LastBlock().Hash()
-	bc.CreateBlock(nonce, previousHash)
-	log.Println("action=mining, status=success")
+// validBalances memutar ulang semua transaksi di chain dan memastikan tidak ada
+// sender yang mengirim lebih dari saldonya pada saat transaksi itu terjadi.
+// Coinbase yang belum matang tidak ikut dihitung sebagai saldo yang bisa
+// dibelanjakan.
+func (bc *Blockchain) validBalances(chain []*Block) bool {
+	balances := make(map[string]Amount)
+	immature := make(map[string]Amount)
+	for i, b := range chain {
+		// Coinbase yang mencapai COINBASE_MATURITY di block ini mulai bisa dipakai
+		if h := i - bc.coinbaseMaturity; h >= 1 && bc.coinbaseMaturity > 0 {
+			coinbase := chain[h].transactions[0]
+			immature[coinbase.recipientBlockchainAddress] -= coinbase.value
+		}
+		for _, t := range b.transactions {
+			if t.senderBlockchainAddress == MINING_SENDER {
+				if bc.coinbaseMaturity > 0 {
+					immature[t.recipientBlockchainAddress] += t.value
+				}
+			} else {
+				sender := t.senderBlockchainAddress
+				cost, err := t.Cost()
+				if err != nil || balances[sender] < immature[sender] || balances[sender]-immature[sender] < cost {
+					log.Printf("Chain invalid: %s overspends at block %d. Balance %s (immature %s), value %s, fee %s",
+						sender, i, balances[sender], immature[sender], t.value, t.fee)
+					return false
+				}
+				balances[t.senderBlockchainAddress] -= cost
+			}
+			balance, err := balances[t.recipientBlockchainAddress].Add(t.value)
+			if err != nil {
+				log.Printf("Chain invalid: balance of %s overflows at block %d",
+					t.recipientBlockchainAddress, i)
+				return false
+			}
+			balances[t.recipientBlockchainAddress] = balance
+		}
+	}
+	return true
+}
 
-	for _, n := range bc.neighbors {
-		endpoint := fmt.Sprintf("http://%s/consensus", n)
-		client := &http.Client{}
+// validHeader memeriksa bagian header block ke-index terhadap block-block
+// sebelumnya (prefix): hash sebelumnya, timestamp, difficulty dan proof-of-work.
+// Cukup untuk memvalidasi header tanpa mengunduh transaksinya.
+func (bc *Blockchain) validHeader(prefix []*Block, b *Block) bool {
+	index := len(prefix)
+	preBlock := prefix[index-1]
+	log.Printf("Checking block %d - PreviousHash: %x, Hash: %x", index, b.previousHash, b.Hash())
 
-		// Membuat permintaan PUT
-		req, err := http.NewRequest("PUT", endpoint, nil)
-		if err != nil {
-			log.Printf("Error creating request to %s: %v", n, err)
-			continue
-		}
+	// Validasi hash sebelumnya
+	if b.previousHash != preBlock.Hash() {
+		log.Printf("Chain invalid: Previous hash mismatch at block %d. Expected %x, got %x",
+			index, preBlock.Hash(), b.previousHash)
+		return false
+	}
+	log.Printf("Block %d: Previous hash valid", index)
 
-		// Mengirim permintaan
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("Error sending request to %s: %v", n, err)
-			continue
-		}
-		defer resp.Body.Close() // Menutup body respons setelah selesai
+	// Validasi timestamp, dipakai untuk retarget difficulty
+	if b.timestamp < preBlock.timestamp ||
+		b.timestamp > time.Now().Add(MAX_FUTURE_BLOCK_TIME_SEC*time.Second).UnixNano() {
+		log.Printf("Chain invalid: Timestamp out of range at block %d", index)
+		return false
+	}
 
-		// Mencatat status respons
-		log.Printf("Response from %s: %s", n, resp.Status)
+	// Validasi difficulty hasil retarget
+	if !validDifficulty(b.difficulty) {
+		log.Printf("Chain invalid: Difficulty %d at block %d out of range", b.difficulty, index)
+		return false
 	}
+	if expected := NextDifficulty(prefix); b.difficulty != expected {
+		log.Printf("Chain invalid: Difficulty at block %d is %d, expected %d",
+			index, b.difficulty, expected)
+		return false
+	}
+
+	// Validasi bukti kerja
+	if !bc.ValidProof(b) {
+		log.Printf("Chain invalid: Proof of work invalid at block %d. Nonce: %d, Transactions: %d",
+			index, b.Nonce(), len(b.Transactions()))
+		return false
+	}
+	log.Printf("Block %d: Proof of work valid", index)
 	return true
 }
 
-func (bc *Blockchain) StartMining() {
-	bc.Mining()
-	_ = time.AfterFunc(time.Second*MINING_TIMER_SEC, bc.StartMining)
-}
+// validBody memeriksa isi transaksi block: merkle root dan pemilik setiap
+// transaksi. Saldo dan nonce diperiksa terhadap seluruh chain di ValidChain.
+func (bc *Blockchain) validBody(index int, b *Block) bool {
+	// Validasi merkle root terhadap isi transaksi
+	if b.merkleRoot != MerkleRoot(b.transactions) {
+		log.Printf("Chain invalid: Merkle root mismatch at block %d", index)
+		return false
+	}
 
-func (bc *Blockchain) CalculateTotalAmount(blockchainAddress string) float32 {
-	var totalAmount float32 = 0.0
-	for _, b := range bc.chain {
-		for _, t := range b.transactions {
-			value := t.value
-			if blockchainAddress == t.recipientBlockchainAddress {
-				totalAmount += value
-			}
+	// Validasi batas ukuran block
+	if len(b.transactions) > MAX_BLOCK_TRANSACTIONS || TransactionsSize(b.transactions) > MAX_BLOCK_SIZE {
+		log.Printf("Chain invalid: Block %d exceeds size limits (%d transactions, %d bytes)",
+			index, len(b.transactions), TransactionsSize(b.transactions))
+		return false
+	}
 
-			if blockchainAddress == t.senderBlockchainAddress {
-				totalAmount -= value
-			}
+	// Validasi posisi dan bentuk coinbase
+	if !validCoinbase(index, b) {
+		return false
+	}
+	coinbase := b.transactions[0].value
+
+	// Validasi pemilik setiap transaksi
+	var fees Amount
+	for _, t := range b.transactions[1:] {
+		var err error
+		if err := bc.VerifyTransaction(t); err != nil {
+			log.Printf("Chain invalid: Transaction from %s at block %d: %v",
+				t.senderBlockchainAddress, index, err)
+			return false
+		}
+		if fees, err = fees.Add(t.fee); err != nil {
+			log.Printf("Chain invalid: Fees overflow at block %d", index)
+			return false
 		}
 	}
-	return totalAmount
+
+	// Coinbase hanya boleh mengambil subsidy tinggi block ditambah fee
+	// transaksi di block
+	subsidy := BlockSubsidy(index)
+	if maxCoinbase, err := fees.Add(subsidy); err != nil || coinbase > maxCoinbase {
+		log.Printf("Chain invalid: Coinbase %s at block %d exceeds subsidy %s plus fees %s",
+			coinbase, index, subsidy, fees)
+		return false
+	}
+	log.Printf("Block %d: Transactions valid", index)
+	return true
 }
 
 func (bc *Blockchain) ValidChain(chain []*Block) bool {
 	// Log awal proses validasi
 	log.Printf("Validating chain with length %d", len(chain))
 
-	// Jika rantai kosong atau hanya satu blok, anggap valid (tergantung kebutuhan)
-	if len(chain) <= 1 {
-		log.Printf("Chain has 0 or 1 block, considered valid by default")
-		return true
+	// Genesis block tidak ditambang, jadi harus sama persis dengan
+	// GenesisBlock
+	if len(chain) == 0 || chain[0].Hash() != GenesisBlock().Hash() {
+		log.Printf("Chain invalid: Missing or unknown genesis block")
+		return false
 	}
+	log.Printf("Starting with block 0 - Hash: %x", chain[0].Hash())
 
-	preBlock := chain[0]
-	currentIndex := 1
-
-	// Log blok awal
-	log.Printf("Starting with block 0 - Hash: %x", preBlock.Hash())
-
-	for currentIndex < len(chain) {
+	for currentIndex := 1; currentIndex < len(chain); currentIndex += 1 {
 		b := chain[currentIndex]
-		log.Printf("Checking block %d - PreviousHash: %x, Hash: %x", currentIndex, b.previousHash, b.Hash())
-
-		// Validasi hash sebelumnya
-		if b.previousHash != preBlock.Hash() {
-			log.Printf("Chain invalid: Previous hash mismatch at block %d. Expected %x, got %x",
-				currentIndex, preBlock.Hash(), b.previousHash)
+		if !bc.validHeader(chain[:currentIndex], b) || !bc.validBody(currentIndex, b) {
 			return false
 		}
-		log.Printf("Block %d: Previous hash valid", currentIndex)
+	}
 
-		// Validasi bukti kerja
-		if !bc.ValidProof(b.Nonce(), b.PreviousHash(), b.Transactions(), MINING_DIFFICULTY) {
-			log.Printf("Chain invalid: Proof of work invalid at block %d. Nonce: %d, Transactions: %d",
-				currentIndex, b.Nonce(), len(b.Transactions()))
-			return false
-		}
-		log.Printf("Block %d: Proof of work valid", currentIndex)
+	if !bc.validBalances(chain) {
+		return false
+	}
+	log.Printf("Chain balances valid")
 
-		preBlock = b
-		currentIndex += 1
+	// Saldo sudah valid, jadi jumlah coinbase dan fee tidak overflow
+	if supply := ChainSupply(chain); supply > MAX_SUPPLY {
+		log.Printf("Chain invalid: Supply %s exceeds maximum %s", supply, MAX_SUPPLY)
+		return false
+	}
+
+	if !bc.validNonces(chain) {
+		return false
 	}
+	log.Printf("Chain nonces valid")
 
 	log.Printf("Chain validation successful")
 	return true
@@ -411,122 +653,170 @@ func (bc *Blockchain) ResolveConflicts() bool {
 	log.Println("=====================================")
 	log.Println("Starting ResolveConflicts process")
 
-	var longestChain []*Block = nil
-	maxLength := len(bc.chain)
-
-	// Log panjang rantai lokal saat ini
-	log.Printf("Current local chain length: %d", maxLength)
+	// Log panjang dan total kerja rantai lokal saat ini
+	local := bc.Chain()
+	log.Printf("Current local chain length: %d, work: %s", len(local), ChainWork(local))
 
+	replaced := false
 	for _, n := range bc.neighbors {
-		endpoint := fmt.Sprintf("http://%s/chain", n)
-
-		// Mengirim HTTP GET request
-		resp, err := http.Get(endpoint)
-		if err != nil {
-			log.Printf("Failed to get chain from %s: %v", n, err)
-			continue
-		}
-		defer resp.Body.Close() // Pastikan body ditutup
-
-		// Log status respons
-		log.Printf("Response from %s: %s", n, resp.Status)
-
-		if resp.StatusCode == 200 {
-			var bcResp Blockchain
-			decoder := json.NewDecoder(resp.Body)
-			err := decoder.Decode(&bcResp)
-			if err != nil {
-				log.Printf("Failed to decode chain from %s: %v", n, err)
-				continue
-			}
-
-			chain := bcResp.Chain()
-			chainLength := len(chain)
-
-			// Log detail rantai yang diterima
-			log.Printf("Chain received from %s - Length: %d", n, chainLength)
-
-			if chainLength > maxLength && bc.ValidChain(chain) {
-				log.Printf("Found longer valid chain from %s - New length: %d", n, chainLength)
-				maxLength = chainLength
-				longestChain = chain
-			} else {
-				log.Printf("Chain from %s not longer or invalid - Length: %d", n, chainLength)
-			}
-		} else {
-			log.Printf("Non-200 response from %s: %s", n, resp.Status)
+		if bc.SyncWithPeer(n) {
+			replaced = true
 		}
 	}
 
 	// Menentukan hasil akhir
-	if longestChain != nil {
-		bc.chain = longestChain
-		log.Printf("Resolve conflicts: Chain replaced with length %d", len(longestChain))
-		log.Println("ResolveConflicts process completed")
-		log.Println("=====================================")
-		return true
-	}
-	log.Printf("Resolve conflicts: Chain not replaced, keeping length %d", maxLength)
+	if local := bc.Chain(); replaced {
+		log.Printf("Resolve conflicts: Chain replaced with length %d, work %s", len(local), ChainWork(local))
+	} else {
+		log.Printf("Resolve conflicts: Chain not replaced, keeping length %d", len(local))
+	}
 	log.Println("ResolveConflicts process completed")
 	log.Println("=====================================")
-	return false
+	return replaced
 }
 
 type Transaction struct {
 	senderBlockchainAddress    string
 	recipientBlockchainAddress string
-	value                      float32
+	value                      Amount
+	fee                        Amount
+	nonce                      uint64
+	senderPublicKey            utils.Verifier
+	signature                  []byte
 }
 
-func NewTransaction(sender string, recipient string, value float32) *Transaction {
+func NewTransaction(sender string, recipient string, value Amount, fee Amount, nonce uint64) *Transaction {
 	return &Transaction{
 		senderBlockchainAddress:    sender,
 		recipientBlockchainAddress: recipient,
 		value:                      value,
+		fee:                        fee,
+		nonce:                      nonce,
 	}
 }
 
+// NewUnsignedTransaction membuat transaksi beserta public key sender yang
+// akan menandatanganinya, karena scheme key itu ikut masuk SigningBytes.
+func NewUnsignedTransaction(senderPublicKey utils.Verifier, sender string, recipient string,
+	value Amount, fee Amount, nonce uint64) *Transaction {
+	t := NewTransaction(sender, recipient, value, fee, nonce)
+	t.senderPublicKey = senderPublicKey
+	return t
+}
+
+func (t *Transaction) Value() Amount { return t.value }
+func (t *Transaction) Fee() Amount   { return t.fee }
+
+// Cost adalah jumlah yang dibayar sender: value ditambah fee untuk miner.
+func (t *Transaction) Cost() (Amount, error) {
+	return t.value.Add(t.fee)
+}
+
 func (transaction *Transaction) Print() {
 	fmt.Printf("%s\n", strings.Repeat("-", 40))
 	fmt.Printf(" sender_blockchain_address      %s\n", transaction.senderBlockchainAddress)
 	fmt.Printf(" recipient_blockchain_address   %s\n", transaction.recipientBlockchainAddress)
-	fmt.Printf(" value                          %.1f\n", transaction.value)
+	fmt.Printf(" value                          %s\n", transaction.value)
+	fmt.Printf(" fee                            %s\n", transaction.fee)
+	fmt.Printf(" nonce                          %d\n", transaction.nonce)
+}
+
+// Hash adalah ID transaksi sekaligus daun Merkle tree di block.
+func (t *Transaction) Hash() [32]byte {
+	return sha256.Sum256(t.Bytes())
 }
 
 func (t *Transaction) MarshalJSON() ([]byte, error) {
+	var scheme, publicKey, signature string
+	if t.senderPublicKey != nil {
+		scheme = string(t.senderPublicKey.Scheme())
+		publicKey = utils.PublicKeyHex(t.senderPublicKey)
+	}
+	if t.signature != nil {
+		signature = hex.EncodeToString(t.signature)
+	}
 	return json.Marshal(struct {
-		Sender    string  `json:"sender_blockchain_address"`
-		Recipient string  `json:"recipient_blockchain_address"`
-		Value     float32 `json:"value"`
+		Sender    string `json:"sender_blockchain_address"`
+		Recipient string `json:"recipient_blockchain_address"`
+		Value     Amount `json:"value"`
+		Fee       Amount `json:"fee"`
+		Nonce     uint64 `json:"nonce"`
+		Scheme    string `json:"scheme,omitempty"`
+		PublicKey string `json:"sender_public_key,omitempty"`
+		Signature string `json:"signature,omitempty"`
 	}{
 		Sender:    t.senderBlockchainAddress,
 		Recipient: t.recipientBlockchainAddress,
 		Value:     t.value,
+		Fee:       t.fee,
+		Nonce:     t.nonce,
+		Scheme:    scheme,
+		PublicKey: publicKey,
+		Signature: signature,
 	})
 }
 
 func (t *Transaction) UnmarshalJSON(data []byte) error {
+	var schemeStr, publicKey, signature string
 	v := &struct {
-		Sender    *string  `json:"sender_blockchain_address"`
-		Recipient *string  `json:"recipient_blockchain_address"`
-		Value     *float32 `json:"value"`
+		Sender    *string `json:"sender_blockchain_address"`
+		Recipient *string `json:"recipient_blockchain_address"`
+		Value     *Amount `json:"value"`
+		Fee       *Amount `json:"fee"`
+		Nonce     *uint64 `json:"nonce"`
+		Scheme    *string `json:"scheme"`
+		PublicKey *string `json:"sender_public_key"`
+		Signature *string `json:"signature"`
 	}{
 		Sender:    &t.senderBlockchainAddress,
 		Recipient: &t.recipientBlockchainAddress,
 		Value:     &t.value,
+		Fee:       &t.fee,
+		Nonce:     &t.nonce,
+		Scheme:    &schemeStr,
+		PublicKey: &publicKey,
+		Signature: &signature,
 	}
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
+	scheme, err := utils.ParseScheme(schemeStr)
+	if err != nil {
+		return err
+	}
+	// Coinbase tidak memiliki public key dan signature
+	if publicKey != "" {
+		if t.senderPublicKey, err = utils.ParsePublicKey(scheme, publicKey); err != nil {
+			return err
+		}
+	}
+	if signature != "" {
+		if t.signature, err = utils.ParseSignature(scheme, signature); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// TransactionRequest adalah transaksi bertanda tangan yang dikirim ke node.
+// Scheme boleh kosong untuk key P-256 dan Fee boleh kosong untuk fee nol.
 type TransactionRequest struct {
-	SenderBlockchainAddress    *string  `json:"sender_blockchain_address"`
-	RecipientBlockchainAddress *string  `json:"recipient_blockchain_address"`
-	SenderPublicKey            *string  `json:"sender_public_key"`
-	Value                      *float32 `json:"value"`
-	Signature                  *string  `json:"signature"`
+	SenderBlockchainAddress    *string `json:"sender_blockchain_address"`
+	RecipientBlockchainAddress *string `json:"recipient_blockchain_address"`
+	Scheme                     *string `json:"scheme,omitempty"`
+	SenderPublicKey            *string `json:"sender_public_key"`
+	Value                      *Amount `json:"value"`
+	Fee                        *Amount `json:"fee,omitempty"`
+	Nonce                      *uint64 `json:"nonce"`
+	Signature                  *string `json:"signature"`
+}
+
+// FeeAmount mengembalikan fee request, atau nol bila tidak diisi.
+func (tr *TransactionRequest) FeeAmount() Amount {
+	if tr.Fee == nil {
+		return 0
+	}
+	return *tr.Fee
 }
 
 func (tr *TransactionRequest) Validate() bool {
@@ -534,19 +824,46 @@ func (tr *TransactionRequest) Validate() bool {
 		tr.RecipientBlockchainAddress == nil ||
 		tr.SenderPublicKey == nil ||
 		tr.Value == nil ||
+		tr.Nonce == nil ||
 		tr.Signature == nil {
 		return false
 	}
 	return true
 }
 
+// KeyAndSignature mengurai public key dan signature sesuai scheme request;
+// format yang salah menghasilkan error, bukan panic.
+func (tr *TransactionRequest) KeyAndSignature() (utils.Verifier, []byte, error) {
+	var schemeStr string
+	if tr.Scheme != nil {
+		schemeStr = *tr.Scheme
+	}
+	scheme, err := utils.ParseScheme(schemeStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey, err := utils.ParsePublicKey(scheme, *tr.SenderPublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := utils.ParseSignature(scheme, *tr.Signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publicKey, signature, nil
+}
+
+type NonceResponse struct {
+	Nonce uint64 `json:"nonce"`
+}
+
 type AmountResponse struct {
-	Amount float32 `json:"amount"`
+	Amount Amount `json:"amount"`
 }
 
 func (ar *AmountResponse) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Amount float32 `json:"amount"`
+		Amount Amount `json:"amount"`
 	}{
 		Amount: ar.Amount,
 	})
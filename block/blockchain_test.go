@@ -0,0 +1,173 @@
+package block
+
+import (
+	"context"
+	"encoding/json"
+	"learn-blockchain/utils"
+	"strings"
+	"testing"
+)
+
+func TestBlockJSON(t *testing.T) {
+	coinbase := NewTransaction(MINING_SENDER, "miner", BlockSubsidy(1), 0, 1)
+	b := CreateNewBlock(7, [32]byte{1, 2, 3}, INITIAL_DIFFICULTY, []*Transaction{coinbase})
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Block
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Hash() != b.Hash() {
+		t.Errorf("decoded hash = %x, want %x", decoded.Hash(), b.Hash())
+	}
+	if len(decoded.transactions) != 1 || decoded.transactions[0].Hash() != coinbase.Hash() {
+		t.Errorf("decoded transactions do not match")
+	}
+}
+
+func TestBlockUnmarshalJSONInvalid(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"short previous hash", `{"previous_hash":"abcd","merkle_root":"` + hash + `"}`},
+		{"long previous hash", `{"previous_hash":"` + hash + `00","merkle_root":"` + hash + `"}`},
+		{"non-hex previous hash", `{"previous_hash":"` + strings.Repeat("zz", 32) + `","merkle_root":"` + hash + `"}`},
+		{"missing previous hash", `{"merkle_root":"` + hash + `"}`},
+		{"short merkle root", `{"previous_hash":"` + hash + `","merkle_root":"ab"}`},
+		{"nil transaction", `{"previous_hash":"` + hash + `","merkle_root":"` + hash + `","transactions":[null]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b Block
+			if err := json.Unmarshal([]byte(tt.json), &b); err == nil {
+				t.Error("Unmarshal succeeded, want error")
+			}
+		})
+	}
+}
+
+// mineTestBlock menambang block berisi coinbase untuk miner dan transactions
+// di atas chain sehingga lolos ValidChain.
+func mineTestBlock(t *testing.T, chain []*Block, miner string, transactions ...*Transaction) *Block {
+	t.Helper()
+	b := nextTestBlock(chain, miner, transactions...)
+	mined, err := ProofOfWork(context.Background(), b.previousHash, b.difficulty, b.transactions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mined
+}
+
+// fundedTestChain mengembalikan chain dengan satu block yang memberi reward
+// mining ke address signer.
+func fundedTestChain(t *testing.T) (*Blockchain, utils.Signer, string) {
+	t.Helper()
+	signer, sender := newTestSigner(t)
+	bc := NewBlockchain(sender, 0, nil, 0)
+	bc.appendBlock(mineTestBlock(t, bc.chain, sender))
+	return bc, signer, sender
+}
+
+func TestValidChainBalances(t *testing.T) {
+	bc, signer, sender := fundedTestChain(t)
+	balance := BlockSubsidy(1)
+	unfunded, unfundedAddress := newTestSigner(t)
+
+	tests := []struct {
+		name   string
+		blocks [][]*Transaction
+		want   bool
+	}{
+		{name: "empty block", blocks: [][]*Transaction{{}}, want: true},
+		{name: "spend balance", blocks: [][]*Transaction{
+			{signedTransaction(t, signer, sender, "recipient", balance-10, 10, 0)},
+		}, want: true},
+		{name: "overspend", blocks: [][]*Transaction{
+			{signedTransaction(t, signer, sender, "recipient", balance, 1, 0)},
+		}, want: false},
+		{name: "overspend across blocks", blocks: [][]*Transaction{
+			{signedTransaction(t, signer, sender, "recipient", balance/2, 0, 0)},
+			{signedTransaction(t, signer, sender, "recipient", balance/2+1, 0, 1)},
+		}, want: false},
+		{name: "unfunded sender", blocks: [][]*Transaction{
+			{signedTransaction(t, unfunded, unfundedAddress, "recipient", 1, 0, 0)},
+		}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := append([]*Block(nil), bc.chain...)
+			for _, transactions := range tt.blocks {
+				chain = append(chain, mineTestBlock(t, chain, "miner", transactions...))
+			}
+			if got := bc.ValidChain(chain); got != tt.want {
+				t.Errorf("ValidChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidChainNonces(t *testing.T) {
+	bc, signer, sender := fundedTestChain(t)
+	pay := func(nonce uint64) *Transaction {
+		return signedTransaction(t, signer, sender, "recipient", 1, 0, nonce)
+	}
+	first := pay(0)
+
+	tests := []struct {
+		name   string
+		blocks [][]*Transaction
+		want   bool
+	}{
+		{name: "sequential", blocks: [][]*Transaction{{pay(0), pay(1)}, {pay(2)}}, want: true},
+		{name: "starts at one", blocks: [][]*Transaction{{pay(1)}}, want: false},
+		{name: "gap", blocks: [][]*Transaction{{pay(0)}, {pay(2)}}, want: false},
+		{name: "out of order", blocks: [][]*Transaction{{pay(1), pay(0)}}, want: false},
+		{name: "replay in next block", blocks: [][]*Transaction{{first}, {first}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := append([]*Block(nil), bc.chain...)
+			for _, transactions := range tt.blocks {
+				chain = append(chain, mineTestBlock(t, chain, "miner", transactions...))
+			}
+			if got := bc.ValidChain(chain); got != tt.want {
+				t.Errorf("ValidChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidChainGenesis(t *testing.T) {
+	heavy := GenesisBlock()
+	heavy.difficulty = 200
+	minted := CreateNewBlock(0, GenesisBlock().previousHash, INITIAL_DIFFICULTY,
+		[]*Transaction{NewTransaction(MINING_SENDER, "attacker", 500*AMOUNT_UNIT, 0, 0)})
+
+	tests := []struct {
+		name  string
+		chain []*Block
+		want  bool
+	}{
+		{name: "empty", chain: nil, want: false},
+		{name: "genesis only", chain: []*Block{GenesisBlock()}, want: true},
+		{name: "genesis with other difficulty", chain: []*Block{heavy}, want: false},
+		{name: "genesis with coinbase", chain: []*Block{minted}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := NewBlockchain("local", 0, nil, 0)
+			if got := bc.ValidChain(tt.chain); got != tt.want {
+				t.Errorf("ValidChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := NewBlockchain("other", 0, nil, 0).Chain()[0].Hash(); got != GenesisBlock().Hash() {
+		t.Errorf("new chain starts with %x, want the fixed genesis block", got)
+	}
+}
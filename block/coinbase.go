@@ -0,0 +1,63 @@
+package block
+
+import "log"
+
+// COINBASE_MATURITY adalah jumlah block yang harus ditambang di atas sebuah
+// coinbase sebelum koinnya boleh dibelanjakan. Coinbase di tinggi h baru bisa
+// dipakai oleh transaksi di block h+COINBASE_MATURITY atau lebih tinggi,
+// sehingga reward dari block yang mungkin hilang karena reorg tidak langsung
+// beredar.
+const COINBASE_MATURITY = 10
+
+func (bc *Blockchain) CoinbaseMaturity() int {
+	return bc.coinbaseMaturity
+}
+
+// validCoinbase memastikan block ke-index memiliki tepat satu coinbase di
+// posisi pertama, tanpa public key, signature maupun fee, dengan nonce sama
+// dengan tinggi block supaya hash setiap coinbase unik.
+func validCoinbase(index int, b *Block) bool {
+	if len(b.transactions) == 0 || b.transactions[0].senderBlockchainAddress != MINING_SENDER {
+		log.Printf("Chain invalid: Block %d does not start with a coinbase", index)
+		return false
+	}
+	for i, t := range b.transactions[1:] {
+		if t.senderBlockchainAddress == MINING_SENDER {
+			log.Printf("Chain invalid: Extra coinbase at position %d in block %d", i+1, index)
+			return false
+		}
+	}
+
+	coinbase := b.transactions[0]
+	if coinbase.senderPublicKey != nil || len(coinbase.signature) != 0 || coinbase.fee != 0 {
+		log.Printf("Chain invalid: Coinbase at block %d carries a key, signature or fee", index)
+		return false
+	}
+	if coinbase.nonce != uint64(index) {
+		log.Printf("Chain invalid: Coinbase at block %d has nonce %d", index, coinbase.nonce)
+		return false
+	}
+	return true
+}
+
+// immatureAmount adalah total coinbase milik blockchainAddress yang belum
+// boleh dibelanjakan oleh transaksi di block setinggi height.
+func (bc *Blockchain) immatureAmount(chain []*Block, height int, blockchainAddress string) Amount {
+	var immature Amount
+	for h := height - bc.coinbaseMaturity + 1; h < len(chain) && h <= height; h++ {
+		if h < 1 {
+			continue
+		}
+		if coinbase := chain[h].transactions[0]; coinbase.recipientBlockchainAddress == blockchainAddress {
+			immature += coinbase.value
+		}
+	}
+	return immature
+}
+
+// ImmatureAmount adalah reward mining blockchainAddress yang belum matang
+// untuk block berikutnya.
+func (bc *Blockchain) ImmatureAmount(blockchainAddress string) Amount {
+	chain := bc.Chain()
+	return bc.immatureAmount(chain, len(chain), blockchainAddress)
+}
@@ -0,0 +1,73 @@
+package block
+
+import "testing"
+
+func TestValidCoinbase(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	coinbase := func(nonce uint64) *Transaction {
+		return NewTransaction(MINING_SENDER, "miner", BlockSubsidy(3), 0, nonce)
+	}
+	payment := signedTransaction(t, signer, sender, "recipient", 1, 0, 0)
+	signed := coinbase(3)
+	signed.senderPublicKey = signer.Public()
+	withFee := coinbase(3)
+	withFee.fee = 1
+
+	tests := []struct {
+		name         string
+		transactions []*Transaction
+		want         bool
+	}{
+		{"coinbase only", []*Transaction{coinbase(3)}, true},
+		{"coinbase and payment", []*Transaction{coinbase(3), payment}, true},
+		{"empty block", nil, false},
+		{"coinbase not first", []*Transaction{payment, coinbase(3)}, false},
+		{"two coinbases", []*Transaction{coinbase(3), coinbase(3)}, false},
+		{"coinbase with public key", []*Transaction{signed}, false},
+		{"coinbase with fee", []*Transaction{withFee}, false},
+		{"nonce is not height", []*Transaction{coinbase(2)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := CreateNewBlock(0, [32]byte{}, INITIAL_DIFFICULTY, tt.transactions)
+			if got := validCoinbase(3, b); got != tt.want {
+				t.Errorf("validCoinbase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoinbaseMaturity(t *testing.T) {
+	signer, miner := newTestSigner(t)
+	bc := NewBlockchain(miner, 0, nil, 3)
+	for i := 0; i < 3; i++ {
+		bc.appendBlock(mineTestBlock(t, bc.chain, miner))
+	}
+
+	// Coinbase di tinggi 1 baru boleh dibelanjakan di block 4
+	if got, want := bc.ImmatureAmount(miner), BlockSubsidy(2)+BlockSubsidy(3); got != want {
+		t.Errorf("ImmatureAmount() = %s, want %s", got, want)
+	}
+	if got := bc.ImmatureAmount("other"); got != 0 {
+		t.Errorf("ImmatureAmount(other) = %s, want 0", got)
+	}
+
+	tests := []struct {
+		name  string
+		value Amount
+		want  bool
+	}{
+		{"spend mature reward", BlockSubsidy(1), true},
+		{"spend immature reward", BlockSubsidy(1) + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := append([]*Block(nil), bc.chain...)
+			tx := signedTransaction(t, signer, miner, "recipient", tt.value, 0, 0)
+			chain = append(chain, mineTestBlock(t, chain, "other", tx))
+			if got := bc.ValidChain(chain); got != tt.want {
+				t.Errorf("ValidChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
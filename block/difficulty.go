@@ -0,0 +1,99 @@
+package block
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+	"time"
+)
+
+// Difficulty dihitung dalam jumlah bit nol di awal hash block. Setiap
+// DIFFICULTY_ADJUSTMENT_INTERVAL block, difficulty disesuaikan agar rata-rata
+// waktu antar block mendekati TARGET_BLOCK_TIME_SEC. Miner tidak pernah
+// menunggu di antara block, jadi jarak waktu block hanya ditentukan oleh
+// difficulty dan retarget bisa menaikkan maupun menurunkannya.
+const (
+	INITIAL_DIFFICULTY             = 12
+	MIN_DIFFICULTY                 = 1
+	MAX_DIFFICULTY                 = 255
+	MAX_DIFFICULTY_STEP            = 2
+	DIFFICULTY_ADJUSTMENT_INTERVAL = 10
+	TARGET_BLOCK_TIME_SEC          = 20
+	MAX_FUTURE_BLOCK_TIME_SEC      = 120
+)
+
+// validDifficulty melaporkan apakah difficulty berada di [MIN_DIFFICULTY,
+// MAX_DIFFICULTY]. Difficulty dari peer harus lolos pemeriksaan ini sebelum
+// dipakai BlockWork.
+func validDifficulty(difficulty int) bool {
+	return difficulty >= MIN_DIFFICULTY && difficulty <= MAX_DIFFICULTY
+}
+
+// NextDifficulty menghitung difficulty yang wajib dipakai block berikutnya
+// setelah chain. Di luar titik retarget nilainya sama dengan block terakhir.
+func NextDifficulty(chain []*Block) int {
+	if len(chain) == 0 {
+		return INITIAL_DIFFICULTY
+	}
+	last := chain[len(chain)-1]
+	height := len(chain)
+	if height <= DIFFICULTY_ADJUSTMENT_INTERVAL || height%DIFFICULTY_ADJUSTMENT_INTERVAL != 0 {
+		return last.difficulty
+	}
+
+	first := chain[height-DIFFICULTY_ADJUSTMENT_INTERVAL]
+	actual := last.timestamp - first.timestamp
+	if actual < 1 {
+		actual = 1
+	}
+	expected := int64(DIFFICULTY_ADJUSTMENT_INTERVAL-1) * int64(TARGET_BLOCK_TIME_SEC*time.Second)
+
+	// Satu bit difficulty menggandakan kerja yang dibutuhkan
+	step := int(math.Round(math.Log2(float64(expected) / float64(actual))))
+	if step > MAX_DIFFICULTY_STEP {
+		step = MAX_DIFFICULTY_STEP
+	}
+	if step < -MAX_DIFFICULTY_STEP {
+		step = -MAX_DIFFICULTY_STEP
+	}
+
+	difficulty := last.difficulty + step
+	if difficulty < MIN_DIFFICULTY {
+		difficulty = MIN_DIFFICULTY
+	}
+	if difficulty > MAX_DIFFICULTY {
+		difficulty = MAX_DIFFICULTY
+	}
+	return difficulty
+}
+
+// leadingZeroBits menghitung jumlah bit nol di awal hash.
+func leadingZeroBits(hash [32]byte) int {
+	n := 0
+	for _, b := range hash {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// BlockWork adalah perkiraan jumlah hash yang dibutuhkan untuk menemukan
+// block dengan difficulty tersebut, yaitu 2^difficulty. Block dengan
+// difficulty di luar validDifficulty tidak memiliki kerja.
+func BlockWork(b *Block) *big.Int {
+	if !validDifficulty(b.difficulty) {
+		return new(big.Int)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(b.difficulty))
+}
+
+// ChainWork adalah total kerja seluruh block di chain.
+func ChainWork(chain []*Block) *big.Int {
+	work := new(big.Int)
+	for _, b := range chain {
+		work.Add(work, BlockWork(b))
+	}
+	return work
+}
@@ -0,0 +1,79 @@
+package block
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+// timedChain membuat chain sepanjang height dengan difficulty yang sama dan
+// jarak waktu antar block sebesar interval.
+func timedChain(height int, difficulty int, interval time.Duration) []*Block {
+	chain := make([]*Block, height)
+	for i := range chain {
+		chain[i] = &Block{timestamp: int64(i) * int64(interval), difficulty: difficulty}
+	}
+	return chain
+}
+
+func TestNextDifficulty(t *testing.T) {
+	target := TARGET_BLOCK_TIME_SEC * time.Second
+	tests := []struct {
+		name       string
+		height     int
+		difficulty int
+		interval   time.Duration
+		want       int
+	}{
+		{"empty chain", 0, 0, target, INITIAL_DIFFICULTY},
+		{"between retargets", 15, 12, target / 8, 12},
+		{"first interval is not retargeted", DIFFICULTY_ADJUSTMENT_INTERVAL, 12, target / 8, 12},
+		{"on target", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, 12, target, 12},
+		{"twice as fast", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, 12, target / 2, 13},
+		{"twice as slow", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, 12, target * 2, 11},
+		{"step is clamped up", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, 12, target / 64, 12 + MAX_DIFFICULTY_STEP},
+		{"step is clamped down", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, 12, target * 64, 12 - MAX_DIFFICULTY_STEP},
+		{"same timestamps", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, 12, 0, 12 + MAX_DIFFICULTY_STEP},
+		{"minimum difficulty", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, MIN_DIFFICULTY, target * 64, MIN_DIFFICULTY},
+		{"maximum difficulty", 2 * DIFFICULTY_ADJUSTMENT_INTERVAL, MAX_DIFFICULTY, target / 64, MAX_DIFFICULTY},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextDifficulty(timedChain(tt.height, tt.difficulty, tt.interval)); got != tt.want {
+				t.Errorf("NextDifficulty() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		hash [32]byte
+		want int
+	}{
+		{[32]byte{0x80}, 0},
+		{[32]byte{0x01}, 7},
+		{[32]byte{0, 0x10}, 11},
+		{[32]byte{}, 256},
+	}
+	for _, tt := range tests {
+		if got := leadingZeroBits(tt.hash); got != tt.want {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", tt.hash[:2], got, tt.want)
+		}
+	}
+}
+
+func TestChainWork(t *testing.T) {
+	chain := []*Block{{difficulty: 1}, {difficulty: 3}, {difficulty: 4}}
+	if got := ChainWork(chain); got.Cmp(big.NewInt(2+8+16)) != 0 {
+		t.Errorf("ChainWork() = %s, want 26", got)
+	}
+	if got := BlockWork(&Block{difficulty: MAX_DIFFICULTY}); got.BitLen() != MAX_DIFFICULTY+1 {
+		t.Errorf("BlockWork(MAX_DIFFICULTY) has %d bits", got.BitLen())
+	}
+	for _, difficulty := range []int{-1, 0, MAX_DIFFICULTY + 1, 1 << 20} {
+		if got := BlockWork(&Block{difficulty: difficulty}); got.Sign() != 0 {
+			t.Errorf("BlockWork(difficulty %d) = %s, want 0", difficulty, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package block
+
+import (
+	"crypto/sha256"
+	"learn-blockchain/utils"
+)
+
+// Versi format biner yang di-hash dan ditandatangani. Naikkan bila urutan
+// atau tipe field berubah.
+const (
+	BLOCK_ENCODING_VERSION       = 2
+	TRANSACTION_ENCODING_VERSION = 4
+)
+
+// HeaderBytes adalah encoding kanonik header block yang dipakai untuk
+// Block.Hash dan proof-of-work.
+func (b *Block) HeaderBytes() []byte {
+	e := utils.NewEncoder()
+	e.WriteUint8(BLOCK_ENCODING_VERSION)
+	e.WriteInt64(b.timestamp)
+	e.WriteUint64(uint64(b.nonce))
+	e.WriteFixed(b.previousHash[:])
+	e.WriteFixed(b.merkleRoot[:])
+	e.WriteUint8(uint8(b.difficulty))
+	return e.Bytes()
+}
+
+// SigningBytes adalah bagian transaksi yang ditandatangani oleh wallet.
+// Scheme public key sender ikut ditandatangani supaya signature tidak bisa
+// dipakai dengan key yang sama di bawah scheme lain. Coinbase tidak memiliki
+// public key, sehingga scheme-nya kosong.
+func (t *Transaction) SigningBytes() []byte {
+	var scheme utils.Scheme
+	if t.senderPublicKey != nil {
+		scheme = t.senderPublicKey.Scheme()
+	}
+	e := utils.NewEncoder()
+	e.WriteUint8(TRANSACTION_ENCODING_VERSION)
+	e.WriteString(string(scheme))
+	e.WriteString(t.senderBlockchainAddress)
+	e.WriteString(t.recipientBlockchainAddress)
+	e.WriteUint64(uint64(t.value))
+	e.WriteUint64(uint64(t.fee))
+	e.WriteUint64(t.nonce)
+	return e.Bytes()
+}
+
+func (t *Transaction) SigningHash() [32]byte {
+	return sha256.Sum256(t.SigningBytes())
+}
+
+// Bytes adalah encoding lengkap transaksi termasuk public key dan signature,
+// dipakai untuk ID transaksi dan daun Merkle tree.
+func (t *Transaction) Bytes() []byte {
+	e := utils.NewEncoder()
+	e.WriteFixed(t.SigningBytes())
+	// Panjang public key berbeda untuk tiap scheme (P-256 64 byte, secp256k1
+	// 33 byte, Ed25519 32 byte), jadi scheme ikut tersirat dalam encoding.
+	if t.senderPublicKey != nil {
+		e.WriteBytes(t.senderPublicKey.Bytes())
+	} else {
+		e.WriteBytes(nil)
+	}
+	e.WriteBytes(t.signature)
+	return e.Bytes()
+}
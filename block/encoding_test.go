@@ -0,0 +1,90 @@
+package block
+
+import (
+	"bytes"
+	"errors"
+	"learn-blockchain/utils"
+	"testing"
+)
+
+var testSchemes = []utils.Scheme{utils.SCHEME_P256, utils.SCHEME_SECP256K1, utils.SCHEME_ED25519}
+
+func TestSigningBytesCommitToScheme(t *testing.T) {
+	seen := make(map[string]utils.Scheme)
+	for _, scheme := range testSchemes {
+		signer, err := utils.GenerateSigner(scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := NewUnsignedTransaction(signer.Public(), "sender", "recipient", 1, 0, 0)
+		payload := string(tx.SigningBytes())
+		if other, ok := seen[payload]; ok {
+			t.Errorf("%s and %s share the same signing payload", scheme, other)
+		}
+		seen[payload] = scheme
+	}
+
+	coinbase := NewTransaction(MINING_SENDER, "miner", 1, 0, 0)
+	if _, ok := seen[string(coinbase.SigningBytes())]; ok {
+		t.Error("coinbase shares a signing payload with a signed transaction")
+	}
+}
+
+func TestSigningBytesFields(t *testing.T) {
+	base := NewTransaction("sender", "recipient", 100, 10, 3)
+	tests := []struct {
+		name string
+		tx   *Transaction
+	}{
+		{"sender", NewTransaction("other", "recipient", 100, 10, 3)},
+		{"recipient", NewTransaction("sender", "other", 100, 10, 3)},
+		{"value", NewTransaction("sender", "recipient", 101, 10, 3)},
+		{"fee", NewTransaction("sender", "recipient", 100, 11, 3)},
+		{"nonce", NewTransaction("sender", "recipient", 100, 10, 4)},
+		// Batas antar string harus jelas supaya field tidak bisa digeser
+		{"string boundary", NewTransaction("sende", "rrecipient", 100, 10, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if bytes.Equal(tt.tx.SigningBytes(), base.SigningBytes()) {
+				t.Error("changing the field does not change SigningBytes")
+			}
+		})
+	}
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	bc := NewBlockchain("miner", 0, nil, 0)
+	for _, scheme := range testSchemes {
+		t.Run(string(scheme), func(t *testing.T) {
+			signer, err := utils.GenerateSigner(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sender := utils.BlockchainAddress(signer.Public())
+			tx := signedTransaction(t, signer, sender, "recipient", 1, 0, 0)
+			if err := bc.VerifyTransaction(tx); err != nil {
+				t.Fatalf("VerifyTransaction: %v", err)
+			}
+
+			other, _ := newTestSigner(t)
+			tampered := []struct {
+				name string
+				tx   *Transaction
+				want error
+			}{
+				{"value", &Transaction{senderBlockchainAddress: sender, recipientBlockchainAddress: "recipient",
+					value: 2, senderPublicKey: tx.senderPublicKey, signature: tx.signature}, ErrInvalidSignature},
+				{"other key", &Transaction{senderBlockchainAddress: sender, recipientBlockchainAddress: "recipient",
+					value: 1, senderPublicKey: other.Public(), signature: tx.signature}, ErrAddressMismatch},
+				{"no signature", &Transaction{senderBlockchainAddress: sender, recipientBlockchainAddress: "recipient",
+					value: 1, senderPublicKey: tx.senderPublicKey}, ErrInvalidSignature},
+			}
+			for _, tt := range tampered {
+				if err := bc.VerifyTransaction(tt.tx); !errors.Is(err, tt.want) {
+					t.Errorf("%s: VerifyTransaction = %v, want %v", tt.name, err, tt.want)
+				}
+			}
+		})
+	}
+}
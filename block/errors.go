@@ -0,0 +1,63 @@
+package block
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidSignature     = errors.New("invalid transaction signature")
+	ErrAddressMismatch      = errors.New("sender address does not match public key")
+	ErrHighSSignature       = errors.New("signature is not in low-S form")
+	ErrInvalidAmount        = errors.New("invalid amount")
+	ErrNegativeAmount       = errors.New("amount must not be negative")
+	ErrAmountOverflow       = errors.New("amount overflows")
+	ErrCoinbaseTransaction  = errors.New("coinbase transactions are only created by block assembly")
+	ErrDuplicateTransaction = errors.New("transaction is already in the pool")
+	ErrMempoolFull          = errors.New("mempool is full and the transaction fee is too low")
+	ErrMempoolSenderLimit   = errors.New("too many pending transactions from sender")
+	ErrMempoolExpired       = errors.New("transaction expired from the pool")
+)
+
+// InsufficientBalanceError dikembalikan bila sender mencoba membelanjakan
+// lebih dari saldo confirmed dikurangi transaksinya yang masih pending di
+// pool.
+type InsufficientBalanceError struct {
+	Address   string
+	Available Amount
+	Requested Amount
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("insufficient balance for %s: available %s, requested %s",
+		e.Address, e.Available, e.Requested)
+}
+
+// ReplacementFeeError dikembalikan bila transaksi memakai nonce transaksi
+// pending tanpa membayar fee tambahan yang cukup untuk menggantikannya.
+type ReplacementFeeError struct {
+	Address string
+	Nonce   uint64
+	Fee     Amount
+	Minimum Amount
+}
+
+func (e *ReplacementFeeError) Error() string {
+	return fmt.Sprintf("replacement for nonce %d of %s pays fee %s, minimum is %s",
+		e.Nonce, e.Address, e.Fee, e.Minimum)
+}
+
+// NonceError dikembalikan bila transaksi tidak membawa nonce berikutnya yang
+// diharapkan untuk akun itu, misalnya karena transaksi lama diputar ulang.
+type NonceError struct {
+	Address  string
+	Expected uint64
+	Got      uint64
+}
+
+func (e *NonceError) Error() string {
+	if e.Got < e.Expected {
+		return fmt.Sprintf("nonce %d for %s already used, expected %d", e.Got, e.Address, e.Expected)
+	}
+	return fmt.Sprintf("invalid nonce %d for %s, expected %d", e.Got, e.Address, e.Expected)
+}
@@ -0,0 +1,105 @@
+package block
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math/big"
+)
+
+// heavierChain menentukan apakah chain a lebih baik dari chain b: total kerja
+// lebih besar menang, dan bila sama, tip dengan hash terkecil menang supaya
+// semua node memilih chain yang sama tanpa bergantung urutan neighbor.
+// Kedua chain harus sudah divalidasi, karena ChainWork mempercayai
+// difficulty setiap block.
+func heavierChain(a []*Block, aWork *big.Int, b []*Block, bWork *big.Int) bool {
+	if c := aWork.Cmp(bWork); c != 0 {
+		return c > 0
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	aTip := a[len(a)-1].Hash()
+	bTip := b[len(b)-1].Hash()
+	return bytes.Compare(aTip[:], bTip[:]) < 0
+}
+
+// forkPoint mengembalikan index block pertama yang berbeda antara dua chain.
+func forkPoint(a []*Block, b []*Block) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i].Hash() == b[i].Hash() {
+		i += 1
+	}
+	return i
+}
+
+// replaceChain mengganti chain lokal dengan chain yang sudah divalidasi.
+// Bila chain baru hanya memperpanjang chain lokal, mempool cukup dibersihkan
+// dari transaksi yang masuk block baru. Bila terjadi reorg, transaksi dari
+// block lokal yang tidak lagi ada di chain baru (orphaned) dikembalikan ke
+// mempool bersama isi pool lama, setelah diperiksa ulang terhadap state
+// chain baru. Perpanjangan hanya menambahkan block baru ke storage, sedangkan
+// reorg menulis ulang seluruh chain. Chain di memori hanya diganti setelah
+// storage berhasil, jadi bila storage gagal chain lokal tetap seperti semula
+// dan error dikembalikan. Bila penambahan gagal di tengah perpanjangan,
+// hanya block yang sudah tersimpan yang dipakai supaya memori tetap sama
+// dengan isi disk. Pemanggil memegang bc.mux.
+func (bc *Blockchain) replaceChain(chain []*Block) error {
+	fork := forkPoint(bc.chain, chain)
+	orphaned := bc.chain[fork:]
+
+	var persistErr error
+	if bc.storage != nil && len(orphaned) == 0 {
+		for i, b := range chain[fork:] {
+			if err := bc.storage.Append(b); err != nil {
+				if i == 0 {
+					return fmt.Errorf("persist block: %w", err)
+				}
+				chain = chain[:fork+i]
+				persistErr = fmt.Errorf("persist block: %w", err)
+				break
+			}
+		}
+	} else if bc.storage != nil {
+		if err := bc.storage.Replace(chain); err != nil {
+			return fmt.Errorf("persist replaced chain: %w", err)
+		}
+	}
+	bc.chain = chain
+	defer bc.notifyChange()
+
+	if len(orphaned) == 0 {
+		// Remove memeriksa antrean sender terhadap nonce confirmed di tip
+		// baru, jadi transaksi semua block baru dikeluarkan sekaligus
+		var confirmed []*Transaction
+		for _, b := range chain[fork:] {
+			confirmed = append(confirmed, b.transactions...)
+		}
+		bc.mempool.Remove(confirmed)
+		log.Printf("Sync: %d new blocks, %d transactions left in pool", len(chain)-fork, bc.mempool.Len())
+		return persistErr
+	}
+
+	pending := bc.mempool.Clear()
+
+	candidates := make([]*Transaction, 0, len(pending))
+	for _, b := range orphaned {
+		candidates = append(candidates, b.transactions...)
+	}
+	candidates = append(candidates, pending...)
+
+	restored, dropped := 0, 0
+	for _, t := range candidates {
+		if t.senderBlockchainAddress == MINING_SENDER {
+			continue
+		}
+		if err := bc.mempool.Add(t); err != nil {
+			dropped += 1
+			continue
+		}
+		restored += 1
+	}
+	log.Printf("Reorg: fork at block %d, %d orphaned blocks, %d transactions returned to pool, %d dropped",
+		fork, len(orphaned), restored, dropped)
+	return nil
+}
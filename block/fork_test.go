@@ -0,0 +1,222 @@
+package block
+
+import (
+	"bytes"
+	"learn-blockchain/utils"
+	"testing"
+)
+
+func newTestSigner(t *testing.T) (utils.Signer, string) {
+	t.Helper()
+	signer, err := utils.GenerateSigner(utils.SCHEME_P256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, utils.BlockchainAddress(signer.Public())
+}
+
+func signedTransaction(t *testing.T, signer utils.Signer, sender string, recipient string,
+	value Amount, fee Amount, nonce uint64) *Transaction {
+	t.Helper()
+	tx := NewTransaction(sender, recipient, value, fee, nonce)
+	tx.senderPublicKey = signer.Public()
+	h := tx.SigningHash()
+	signature, err := signer.Sign(h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.signature = signature
+	return tx
+}
+
+// nextTestBlock membuat block berisi coinbase untuk miner dan transactions
+// di atas chain. Proof-of-work tidak dihitung karena replaceChain menerima
+// chain yang sudah divalidasi.
+func nextTestBlock(chain []*Block, miner string, transactions ...*Transaction) *Block {
+	height := len(chain)
+	coinbase := NewTransaction(MINING_SENDER, miner, BlockSubsidy(height), 0, uint64(height))
+	return CreateNewBlock(0, chain[height-1].Hash(), NextDifficulty(chain),
+		append([]*Transaction{coinbase}, transactions...))
+}
+
+func TestReplaceChainExtension(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	bc := NewBlockchain(sender, 0, nil, 0)
+	bc.appendBlock(nextTestBlock(bc.chain, sender))
+
+	pool := make([]*Transaction, 3)
+	for i := range pool {
+		pool[i] = signedTransaction(t, signer, sender, "recipient", AMOUNT_UNIT/10, 100, uint64(i))
+	}
+
+	tests := []struct {
+		name   string
+		blocks [][]*Transaction
+		want   []uint64
+	}{
+		{name: "one block", blocks: [][]*Transaction{{pool[0]}}, want: []uint64{1, 2}},
+		{name: "two blocks", blocks: [][]*Transaction{{pool[0]}, {pool[1]}}, want: []uint64{2}},
+		{name: "two blocks, empty first", blocks: [][]*Transaction{{}, {pool[0], pool[1]}}, want: []uint64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewBlockchain(sender, 0, nil, 0)
+			local.chain = append([]*Block(nil), bc.chain...)
+			for _, tx := range pool {
+				if err := local.mempool.Add(tx); err != nil {
+					t.Fatalf("Add(nonce %d): %v", tx.nonce, err)
+				}
+			}
+
+			chain := append([]*Block(nil), local.chain...)
+			for _, transactions := range tt.blocks {
+				chain = append(chain, nextTestBlock(chain, "miner", transactions...))
+			}
+			local.replaceChain(chain)
+
+			if got := poolNonces(local.mempool, sender); !equalNonces(got, tt.want) {
+				t.Errorf("pool nonces = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeavierChain(t *testing.T) {
+	genesis := CreateNewBlock(0, [32]byte{}, INITIAL_DIFFICULTY, nil)
+	chain := func(difficulties ...int) []*Block {
+		c := []*Block{genesis}
+		for _, d := range difficulties {
+			c = append(c, CreateNewBlock(len(c), c[len(c)-1].Hash(), d, nil))
+		}
+		return c
+	}
+	a, b := chain(12, 12), chain(12, 10, 10)
+	lower, higher := a, chain(11, 11, 12)
+	if ha, hb := lower[len(lower)-1].Hash(), higher[len(higher)-1].Hash(); bytes.Compare(ha[:], hb[:]) > 0 {
+		lower, higher = higher, lower
+	}
+
+	tests := []struct {
+		name string
+		a, b []*Block
+		want bool
+	}{
+		{name: "more work", a: chain(12, 12), b: chain(12), want: true},
+		{name: "less work", a: chain(12), b: chain(12, 12), want: false},
+		{name: "more work despite shorter", a: chain(14), b: chain(12, 12), want: true},
+		{name: "longer but lighter", a: b, b: a, want: false},
+		{name: "equal work, lower tip hash", a: lower, b: higher, want: true},
+		{name: "equal work, higher tip hash", a: higher, b: lower, want: false},
+		{name: "same chain", a: a, b: a, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heavierChain(tt.a, ChainWork(tt.a), tt.b, ChainWork(tt.b)); got != tt.want {
+				t.Errorf("heavierChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForkPoint(t *testing.T) {
+	base := testChainBlocks(3)
+	branch := func(chain []*Block, miner string, n int) []*Block {
+		chain = append([]*Block(nil), chain...)
+		for i := 0; i < n; i += 1 {
+			chain = append(chain, nextTestBlock(chain, miner))
+		}
+		return chain
+	}
+	tests := []struct {
+		name string
+		a, b []*Block
+		want int
+	}{
+		{name: "same chain", a: base, b: base, want: 3},
+		{name: "extension", a: base, b: branch(base, "miner", 2), want: 3},
+		{name: "fork", a: branch(base, "alice", 1), b: branch(base, "bob", 2), want: 3},
+		{name: "different genesis", a: base, b: []*Block{CreateNewBlock(1, [32]byte{}, INITIAL_DIFFICULTY, nil)}, want: 0},
+		{name: "empty", a: nil, b: base, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := forkPoint(tt.a, tt.b); got != tt.want {
+				t.Errorf("forkPoint() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceChainReorg(t *testing.T) {
+	bc, signer, sender := fundedTestChain(t)
+	base := append([]*Block(nil), bc.chain...)
+	confirmed := signedTransaction(t, signer, sender, "recipient", AMOUNT_UNIT/10, 100, 0)
+	pending := signedTransaction(t, signer, sender, "recipient", AMOUNT_UNIT/10, 100, 1)
+
+	bc.appendBlock(nextTestBlock(bc.chain, sender, confirmed))
+	if err := bc.mempool.Add(pending); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chain pesaing tidak memuat transaksi nonce 0, jadi transaksi itu harus
+	// kembali ke pool di depan transaksi yang masih pending
+	competing := append([]*Block(nil), base...)
+	competing = append(competing, nextTestBlock(competing, "miner"))
+	competing = append(competing, nextTestBlock(competing, "miner"))
+	bc.replaceChain(competing)
+
+	if got := poolNonces(bc.mempool, sender); !equalNonces(got, []uint64{0, 1}) {
+		t.Errorf("pool nonces = %v, want [0 1]", got)
+	}
+	if bc.mempool.Len() != 2 {
+		t.Errorf("pool has %d transactions, want 2 (coinbase must not be restored)", bc.mempool.Len())
+	}
+}
+
+// TestSyncRejectsInvalidHeavierChain memastikan chain yang lebih berat tetapi
+// tidak valid kalah dari chain lokal.
+func TestSyncRejectsInvalidHeavierChain(t *testing.T) {
+	bc, _, _ := fundedTestChain(t)
+	base := append([]*Block(nil), bc.chain...)
+	thief, thiefAddress := newTestSigner(t)
+
+	tests := []struct {
+		name  string
+		chain func(t *testing.T) []*Block
+	}{
+		{
+			// Genesis buatan peer: tanpa proof-of-work, difficulty tinggi
+			// dan coinbase untuk dirinya sendiri
+			name: "forged genesis",
+			chain: func(t *testing.T) []*Block {
+				genesis := CreateNewBlock(0, GenesisBlock().previousHash, 200,
+					[]*Transaction{NewTransaction(MINING_SENDER, thiefAddress, 500*AMOUNT_UNIT, 0, 0)})
+				return []*Block{genesis}
+			},
+		},
+		{
+			name: "overspend",
+			chain: func(t *testing.T) []*Block {
+				tx := signedTransaction(t, thief, thiefAddress, "recipient", AMOUNT_UNIT, 0, 0)
+				chain := append([]*Block(nil), base...)
+				chain = append(chain, mineTestBlock(t, chain, "peer", tx))
+				return extendTestChain(t, chain, "peer", 2)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewBlockchain("local", 0, nil, 0)
+			local.chain = append([]*Block(nil), base...)
+			p, addr := startTestPeer(t, tt.chain(t))
+			if ChainWork(p.bc.chain).Cmp(ChainWork(local.chain)) <= 0 {
+				t.Fatal("peer chain is not heavier than the local chain")
+			}
+
+			if local.SyncWithPeer(addr) {
+				t.Error("SyncWithPeer() adopted an invalid chain")
+			}
+			assertBlocks(t, local.Chain(), base)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package block
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	MEMPOOL_JOURNAL_FILE = "mempool.log"
+
+	// Journal ditulis ulang setelah berisi sekian record lebih banyak dari
+	// dua kali jumlah transaksi pending.
+	mempoolJournalCompactSlack = 1000
+)
+
+const (
+	journalOpAdd    = "add"
+	journalOpRemove = "remove"
+)
+
+// journalRecord adalah satu entri journal mempool. Record add membawa
+// transaksi bertanda tangan lengkap beserta waktu masuk pool, record remove
+// hanya hash-nya.
+type journalRecord struct {
+	Op          string       `json:"op"`
+	Hash        string       `json:"hash"`
+	Added       int64        `json:"added,omitempty"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+}
+
+// JournaledTransaction adalah transaksi pending yang dibaca ulang dari
+// journal.
+type JournaledTransaction struct {
+	Transaction *Transaction
+	Added       time.Time
+}
+
+// MempoolJournal menyimpan mempool di disk sebagai log append-only berisi
+// record add/remove dengan checksum, memakai format record yang sama dengan
+// FileStorage. Mempool menulis ke journal sambil memegang lock-nya sendiri.
+type MempoolJournal struct {
+	dir     string
+	file    *os.File
+	size    int64
+	records int
+}
+
+func NewMempoolJournal(dir string) (*MempoolJournal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, MEMPOOL_JOURNAL_FILE), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &MempoolJournal{dir: dir, file: file}, nil
+}
+
+// Load memutar ulang journal dan mengembalikan transaksi yang masih pending
+// sesuai urutan masuk pool. Record terakhir yang rusak dipotong seperti di
+// FileStorage.Load.
+func (j *MempoolJournal) Load() ([]*JournaledTransaction, error) {
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(j.file)
+
+	// position menyimpan index order dari record add terakhir sebuah hash,
+	// sehingga transaksi yang dihapus lalu masuk lagi ikut urutan barunya
+	pending := make(map[string]*JournaledTransaction)
+	position := make(map[string]int)
+	var order []string
+	var offset int64
+	records := 0
+	for {
+		payload, err := readStorageRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Journal: dropping broken record at offset %d: %v", offset, err)
+			break
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			log.Printf("Journal: dropping undecodable record at offset %d: %v", offset, err)
+			break
+		}
+		switch rec.Op {
+		case journalOpAdd:
+			if rec.Transaction != nil {
+				if _, ok := pending[rec.Hash]; !ok {
+					position[rec.Hash] = len(order)
+					order = append(order, rec.Hash)
+				}
+				pending[rec.Hash] = &JournaledTransaction{
+					Transaction: rec.Transaction,
+					Added:       time.Unix(0, rec.Added),
+				}
+			}
+		case journalOpRemove:
+			delete(pending, rec.Hash)
+		}
+		records += 1
+		offset += int64(storageRecordHeaderSize + len(payload))
+	}
+
+	info, err := j.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != offset {
+		log.Printf("Journal: truncating mempool journal from %d to %d bytes", info.Size(), offset)
+		if err := j.file.Truncate(offset); err != nil {
+			return nil, err
+		}
+		if err := j.file.Sync(); err != nil {
+			return nil, err
+		}
+	}
+	j.size = offset
+	j.records = records
+
+	transactions := make([]*JournaledTransaction, 0, len(pending))
+	for i, h := range order {
+		if jt, ok := pending[h]; ok && position[h] == i {
+			transactions = append(transactions, jt)
+		}
+	}
+	return transactions, nil
+}
+
+func (j *MempoolJournal) append(rec *journalRecord) error {
+	record, err := encodeStorageRecord(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.WriteAt(record, j.size); err != nil {
+		return err
+	}
+	j.size += int64(len(record))
+	j.records += 1
+	return j.file.Sync()
+}
+
+// Add mencatat bahwa t masuk pool pada waktu added.
+func (j *MempoolJournal) Add(t *Transaction, added time.Time) error {
+	h := t.Hash()
+	return j.append(&journalRecord{
+		Op:          journalOpAdd,
+		Hash:        hex.EncodeToString(h[:]),
+		Added:       added.UnixNano(),
+		Transaction: t,
+	})
+}
+
+// Remove mencatat bahwa transaksi dengan hash h keluar dari pool.
+func (j *MempoolJournal) Remove(h [32]byte) error {
+	return j.append(&journalRecord{Op: journalOpRemove, Hash: hex.EncodeToString(h[:])})
+}
+
+// needsCompaction melaporkan apakah journal sudah tumbuh jauh melebihi
+// jumlah transaksi yang masih pending.
+func (j *MempoolJournal) needsCompaction(pending int) bool {
+	return j.records > 2*pending+mempoolJournalCompactSlack
+}
+
+// Rewrite mengganti journal dengan satu record add per transaksi pending,
+// lewat file sementara dan rename seperti FileStorage.Replace.
+func (j *MempoolJournal) Rewrite(pending []*JournaledTransaction) error {
+	tmpPath := filepath.Join(j.dir, MEMPOOL_JOURNAL_FILE+".tmp")
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, jt := range pending {
+		h := jt.Transaction.Hash()
+		record, err := encodeStorageRecord(&journalRecord{
+			Op:          journalOpAdd,
+			Hash:        hex.EncodeToString(h[:]),
+			Added:       jt.Added.UnixNano(),
+			Transaction: jt.Transaction,
+		})
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if _, err := tmp.Write(record); err != nil {
+			tmp.Close()
+			return err
+		}
+		size += int64(len(record))
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(j.dir, MEMPOOL_JOURNAL_FILE)); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	j.file.Close()
+	j.file = tmp
+	j.size = size
+	j.records = len(pending)
+	return nil
+}
+
+func (j *MempoolJournal) Close() error {
+	return j.file.Close()
+}
@@ -0,0 +1,234 @@
+package block
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestJournal(t *testing.T, dir string) *MempoolJournal {
+	t.Helper()
+	j, err := NewMempoolJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+func journalHashes(pending []*JournaledTransaction) [][32]byte {
+	hashes := make([][32]byte, len(pending))
+	for i, jt := range pending {
+		hashes[i] = jt.Transaction.Hash()
+	}
+	return hashes
+}
+
+func TestMempoolJournalLoad(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	txs := make([]*Transaction, 3)
+	for i := range txs {
+		txs[i] = signedTransaction(t, signer, sender, "recipient", 1, 0, uint64(i))
+	}
+	a, b, c := txs[0], txs[1], txs[2]
+
+	type op struct {
+		remove bool
+		tx     *Transaction
+	}
+	tests := []struct {
+		name string
+		ops  []op
+		want []*Transaction
+	}{
+		{name: "adds", ops: []op{{tx: a}, {tx: b}, {tx: c}}, want: []*Transaction{a, b, c}},
+		{name: "remove", ops: []op{{tx: a}, {tx: b}, {remove: true, tx: a}}, want: []*Transaction{b}},
+		{name: "remove all", ops: []op{{tx: a}, {remove: true, tx: a}}, want: nil},
+		{name: "remove unknown", ops: []op{{tx: a}, {remove: true, tx: c}}, want: []*Transaction{a}},
+		{name: "duplicate add", ops: []op{{tx: a}, {tx: b}, {tx: a}}, want: []*Transaction{a, b}},
+		{name: "re-add", ops: []op{{tx: a}, {remove: true, tx: a}, {tx: b}, {tx: a}}, want: []*Transaction{b, a}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			j := openTestJournal(t, dir)
+			added := time.Unix(1700000000, 0)
+			for i, o := range tt.ops {
+				var err error
+				if o.remove {
+					err = j.Remove(o.tx.Hash())
+				} else {
+					err = j.Add(o.tx, added.Add(time.Duration(i)*time.Second))
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			j.Close()
+
+			pending, err := openTestJournal(t, dir).Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			got := journalHashes(pending)
+			if len(got) != len(tt.want) {
+				t.Fatalf("loaded %d transactions, want %d", len(got), len(tt.want))
+			}
+			for i, tx := range tt.want {
+				if got[i] != tx.Hash() {
+					t.Errorf("transaction %d is nonce %d, want nonce %d", i, pending[i].Transaction.nonce, tx.nonce)
+				}
+			}
+		})
+	}
+}
+
+func TestMempoolJournalTruncatedTail(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	dir := t.TempDir()
+	j := openTestJournal(t, dir)
+	for i := 0; i < 3; i++ {
+		if err := j.Add(signedTransaction(t, signer, sender, "recipient", 1, 0, uint64(i)), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := j.size
+	j.Close()
+
+	path := filepath.Join(dir, MEMPOOL_JOURNAL_FILE)
+	if err := os.Truncate(path, size-3); err != nil {
+		t.Fatal(err)
+	}
+
+	j = openTestJournal(t, dir)
+	pending, err := j.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("loaded %d transactions, want 2", len(pending))
+	}
+
+	// Record baru ditulis tepat setelah record terakhir yang utuh
+	if err := j.Add(signedTransaction(t, signer, sender, "recipient", 1, 0, 2), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	pending, err = openTestJournal(t, dir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 3 {
+		t.Errorf("loaded %d transactions after append, want 3", len(pending))
+	}
+}
+
+func TestMempoolJournalRewrite(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	dir := t.TempDir()
+	j := openTestJournal(t, dir)
+	kept := signedTransaction(t, signer, sender, "recipient", 1, 0, 0)
+	added := time.Unix(1700000000, 0)
+	for i := 1; i <= 5; i++ {
+		tx := signedTransaction(t, signer, sender, "recipient", 1, 0, uint64(i))
+		if err := j.Add(tx, added); err != nil {
+			t.Fatal(err)
+		}
+		if err := j.Remove(tx.Hash()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Rewrite([]*JournaledTransaction{{Transaction: kept, Added: added}}); err != nil {
+		t.Fatal(err)
+	}
+	if j.records != 1 {
+		t.Errorf("journal has %d records after Rewrite, want 1", j.records)
+	}
+	j.Close()
+
+	pending, err := openTestJournal(t, dir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Transaction.Hash() != kept.Hash() || !pending[0].Added.Equal(added) {
+		t.Errorf("loaded %v, want only the rewritten transaction", pending)
+	}
+}
+
+func TestMempoolRestore(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	dir := t.TempDir()
+
+	chain := newTestChain()
+	chain.balances[sender] = 10 * AMOUNT_UNIT
+	mp := NewMempool(chain)
+	if err := mp.Restore(openTestJournal(t, dir)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		mustAdd(t, mp, signedTransaction(t, signer, sender, "recipient", AMOUNT_UNIT, 1000, uint64(i)))
+	}
+	// Pengganti nonce 0 tercatat di journal setelah nonce 1 dan 2
+	replacement := signedTransaction(t, signer, sender, "other", AMOUNT_UNIT, 2000, 0)
+	mustAdd(t, mp, replacement)
+
+	tests := []struct {
+		name      string
+		confirmed uint64
+		want      []uint64
+	}{
+		{name: "replacement first", confirmed: 0, want: []uint64{0, 1, 2}},
+		{name: "confirmed dropped", confirmed: 1, want: []uint64{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoredChain := newTestChain()
+			restoredChain.balances[sender] = 10 * AMOUNT_UNIT
+			restoredChain.nonces[sender] = tt.confirmed
+			restored := NewMempool(restoredChain)
+
+			// Restore memakai salinan journal supaya setiap kasus mulai
+			// dari isi yang sama
+			copyDir := t.TempDir()
+			data, err := os.ReadFile(filepath.Join(dir, MEMPOOL_JOURNAL_FILE))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(copyDir, MEMPOOL_JOURNAL_FILE), data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := restored.Restore(openTestJournal(t, copyDir)); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := poolNonces(restored, sender); !equalNonces(got, tt.want) {
+				t.Fatalf("pool nonces = %v, want %v", got, tt.want)
+			}
+			if tt.confirmed == 0 && restored.Transactions()[0].Hash() != replacement.Hash() {
+				t.Error("restored pool holds the replaced transaction")
+			}
+		})
+	}
+}
+
+func TestMempoolRestoreExpired(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	dir := t.TempDir()
+	j := openTestJournal(t, dir)
+	stale := signedTransaction(t, signer, sender, "recipient", 1, 0, 0)
+	if err := j.Add(stale, time.Now().Add(-MEMPOOL_EXPIRY-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	mp, _ := newTestMempool(t, AMOUNT_UNIT, sender)
+	if err := mp.Restore(j); err != nil {
+		t.Fatal(err)
+	}
+	if mp.Len() != 0 {
+		t.Errorf("pool has %d transactions, want expired transaction dropped", mp.Len())
+	}
+	if j.records != 0 {
+		t.Errorf("journal has %d records after Restore, want 0", j.records)
+	}
+}
@@ -0,0 +1,541 @@
+package block
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Batas mempool. Ukuran dihitung dari encoding biner transaksi
+// (Transaction.Size), sama dengan batas block.
+const (
+	MEMPOOL_MAX_TRANSACTIONS = 5000
+	MEMPOOL_MAX_SIZE         = 32 << 20
+	MEMPOOL_MAX_PER_SENDER   = 100
+	MEMPOOL_EXPIRY           = 3 * time.Hour
+
+	// Transaksi pengganti (nonce sama) harus menaikkan fee paling sedikit
+	// sebesar persentase ini dari fee transaksi yang diganti.
+	MEMPOOL_REPLACEMENT_FEE_BUMP = 10
+)
+
+// mempoolChain adalah state chain yang dibutuhkan Mempool untuk memeriksa
+// transaksi. Diimplementasikan oleh Blockchain.
+type mempoolChain interface {
+	VerifyTransaction(t *Transaction) error
+	CalculateTotalAmount(blockchainAddress string) Amount
+	ImmatureAmount(blockchainAddress string) Amount
+	ConfirmedNonce(blockchainAddress string) uint64
+}
+
+// chainState adalah mempoolChain di atas Blockchain yang membaca bc.chain
+// tanpa mengambil bc.mux. Setiap pemanggil Mempool.Add, Remove dan Restore
+// sudah memegang bc.mux, sehingga method Blockchain yang mengambil lock
+// sendiri akan deadlock bila dipanggil dari sini.
+type chainState struct{ bc *Blockchain }
+
+func (s chainState) VerifyTransaction(t *Transaction) error {
+	return s.bc.VerifyTransaction(t)
+}
+
+func (s chainState) CalculateTotalAmount(blockchainAddress string) Amount {
+	return totalAmount(s.bc.chain, blockchainAddress)
+}
+
+func (s chainState) ImmatureAmount(blockchainAddress string) Amount {
+	return s.bc.immatureAmount(s.bc.chain, len(s.bc.chain), blockchainAddress)
+}
+
+func (s chainState) ConfirmedNonce(blockchainAddress string) uint64 {
+	return confirmedNonce(s.bc.chain, blockchainAddress)
+}
+
+type mempoolEntry struct {
+	transaction *Transaction
+	hash        [32]byte
+	size        int
+	added       time.Time
+	seq         uint64
+}
+
+// Mempool menyimpan transaksi yang belum masuk block. Transaksi setiap
+// sender disimpan berurutan nonce tanpa celah mulai dari nonce confirmed,
+// sehingga setiap isi pool selalu bisa ditambang berurutan.
+type Mempool struct {
+	chain mempoolChain
+
+	mux     sync.Mutex
+	senders map[string][]*mempoolEntry
+	byHash  map[[32]byte]*mempoolEntry
+	size    int
+	seq     uint64
+	journal *MempoolJournal
+	// onChange dipanggil setiap kali isi pool berubah
+	onChange func()
+}
+
+func NewMempool(chain mempoolChain) *Mempool {
+	return &Mempool{
+		chain:   chain,
+		senders: make(map[string][]*mempoolEntry),
+		byHash:  make(map[[32]byte]*mempoolEntry),
+	}
+}
+
+// Add memeriksa transaksi bertanda tangan (signature, nonce dan saldo) lalu
+// memasukkannya ke pool. Bila pool penuh, transaksi dengan fee per byte
+// terendah dikeluarkan asalkan lebih rendah dari transaksi baru. Transaksi
+// dengan nonce yang sudah ada di pool menggantikan transaksi lama bila
+// fee-nya cukup tinggi (lihat replaceLocked).
+func (mp *Mempool) Add(t *Transaction) error {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	return mp.addLocked(t, time.Now())
+}
+
+func (mp *Mempool) addLocked(t *Transaction, added time.Time) error {
+	mp.expireLocked()
+
+	sender := t.senderBlockchainAddress
+	// Coinbase hanya dibuat oleh BlockTemplate, tidak pernah lewat pool
+	if sender == MINING_SENDER {
+		log.Println("ERROR: Coinbase transaction submitted to the pool")
+		return ErrCoinbaseTransaction
+	}
+	if t.value == 0 {
+		log.Println("ERROR: Transaction value must be positive")
+		return ErrInvalidAmount
+	}
+
+	hash := t.Hash()
+	if _, ok := mp.byHash[hash]; ok {
+		log.Printf("ERROR: Transaction %x is already in the pool", hash)
+		return ErrDuplicateTransaction
+	}
+
+	if err := mp.chain.VerifyTransaction(t); err != nil {
+		log.Printf("Error : Verify transaction: %v", err)
+		return err
+	}
+
+	cost, err := t.Cost()
+	if err != nil {
+		return err
+	}
+	e := &mempoolEntry{transaction: t, hash: hash, size: t.Size(), added: added}
+
+	queue := mp.senders[sender]
+	confirmed := mp.chain.ConfirmedNonce(sender)
+	expected := confirmed + uint64(len(queue))
+	if t.nonce >= confirmed && t.nonce < expected {
+		return mp.replaceLocked(int(t.nonce-confirmed), e, cost)
+	}
+	if t.nonce != expected {
+		log.Printf("ERROR: Invalid nonce %d for %s, expected %d", t.nonce, sender, expected)
+		return &NonceError{Address: sender, Expected: expected, Got: t.nonce}
+	}
+
+	if available := mp.availableLocked(sender); available < cost {
+		log.Println("ERROR: Not enough balance in a wallet")
+		return &InsufficientBalanceError{Address: sender, Available: available, Requested: cost}
+	}
+
+	if len(queue) >= MEMPOOL_MAX_PER_SENDER {
+		log.Printf("ERROR: %s already has %d pending transactions", sender, len(queue))
+		return ErrMempoolSenderLimit
+	}
+
+	if !mp.makeRoomLocked(e) {
+		log.Println("ERROR: Mempool is full")
+		return ErrMempoolFull
+	}
+	mp.insertLocked(e)
+	return nil
+}
+
+// replaceLocked mengganti transaksi ke-i di antrean sender dengan e yang
+// memakai nonce yang sama. Fee e harus naik paling sedikit
+// MEMPOOL_REPLACEMENT_FEE_BUMP persen dan fee per byte-nya lebih tinggi,
+// supaya penggantian berulang tidak gratis. Transaksi sender dengan nonce
+// lebih tinggi tetap di pool.
+func (mp *Mempool) replaceLocked(i int, e *mempoolEntry, cost Amount) error {
+	sender := e.transaction.senderBlockchainAddress
+	old := mp.senders[sender][i]
+
+	bump := old.transaction.fee/100*MEMPOOL_REPLACEMENT_FEE_BUMP +
+		old.transaction.fee%100*MEMPOOL_REPLACEMENT_FEE_BUMP/100
+	if bump == 0 {
+		bump = 1
+	}
+	minimum, err := old.transaction.fee.Add(bump)
+	if err != nil || e.transaction.fee < minimum ||
+		!higherFeeRate(e.transaction, e.size, old.transaction, old.size) {
+		log.Printf("ERROR: Replacement for nonce %d of %s pays fee %s, needs at least %s",
+			e.transaction.nonce, sender, e.transaction.fee, minimum)
+		return &ReplacementFeeError{Address: sender, Nonce: e.transaction.nonce, Fee: e.transaction.fee, Minimum: minimum}
+	}
+
+	oldCost, _ := old.transaction.Cost()
+	if available := mp.availableLocked(sender) + oldCost; available < cost {
+		log.Println("ERROR: Not enough balance in a wallet")
+		return &InsufficientBalanceError{Address: sender, Available: available, Requested: cost}
+	}
+	if mp.size-old.size+e.size > MEMPOOL_MAX_SIZE {
+		log.Println("ERROR: Mempool is full")
+		return ErrMempoolFull
+	}
+
+	// Transaksi pengganti mengambil posisi transaksi lama di urutan masuk
+	e.seq = old.seq
+	delete(mp.byHash, old.hash)
+	mp.byHash[e.hash] = e
+	mp.size += e.size - old.size
+	mp.senders[sender][i] = e
+	mp.journalRemoveLocked(old)
+	mp.journalAddLocked(e)
+	mp.changedLocked()
+	log.Printf("Mempool: replaced transaction %x with %x (nonce %d, fee %s -> %s)",
+		old.hash, e.hash, e.transaction.nonce, old.transaction.fee, e.transaction.fee)
+	return nil
+}
+
+// availableLocked adalah saldo sender yang belum dipakai transaksi di pool.
+func (mp *Mempool) availableLocked(sender string) Amount {
+	total := mp.chain.CalculateTotalAmount(sender)
+	locked := mp.chain.ImmatureAmount(sender) + mp.pendingLocked(sender)
+	if locked > total {
+		return 0
+	}
+	return total - locked
+}
+
+func (mp *Mempool) pendingLocked(sender string) Amount {
+	var pending Amount
+	for _, e := range mp.senders[sender] {
+		pending += e.transaction.value + e.transaction.fee
+	}
+	return pending
+}
+
+func (mp *Mempool) insertLocked(e *mempoolEntry) {
+	mp.seq += 1
+	e.seq = mp.seq
+	sender := e.transaction.senderBlockchainAddress
+	mp.senders[sender] = append(mp.senders[sender], e)
+	mp.byHash[e.hash] = e
+	mp.size += e.size
+	mp.journalAddLocked(e)
+	mp.changedLocked()
+}
+
+// dropTailLocked mengeluarkan transaksi sender mulai index i sampai akhir.
+// Transaksi dengan nonce lebih tinggi ikut keluar karena tidak bisa
+// ditambang tanpa transaksi sebelumnya.
+func (mp *Mempool) dropTailLocked(sender string, i int) {
+	queue := mp.senders[sender]
+	for _, e := range queue[i:] {
+		delete(mp.byHash, e.hash)
+		mp.size -= e.size
+		mp.journalRemoveLocked(e)
+	}
+	if i == 0 {
+		delete(mp.senders, sender)
+	} else {
+		mp.senders[sender] = queue[:i]
+	}
+	mp.changedLocked()
+}
+
+// dropHeadLocked mengeluarkan n transaksi pertama sender, yaitu transaksi
+// yang nonce-nya sudah confirmed. Sisa antrean tetap di pool.
+func (mp *Mempool) dropHeadLocked(sender string, n int) {
+	queue := mp.senders[sender]
+	for _, e := range queue[:n] {
+		delete(mp.byHash, e.hash)
+		mp.size -= e.size
+		mp.journalRemoveLocked(e)
+	}
+	if n == len(queue) {
+		delete(mp.senders, sender)
+	} else {
+		mp.senders[sender] = queue[n:]
+	}
+	mp.changedLocked()
+}
+
+func (mp *Mempool) changedLocked() {
+	if mp.onChange != nil {
+		mp.onChange()
+	}
+}
+
+// makeRoomLocked memastikan e muat di pool dengan mengeluarkan transaksi
+// terakhir sender lain yang fee per byte-nya lebih rendah dari e.
+func (mp *Mempool) makeRoomLocked(e *mempoolEntry) bool {
+	for len(mp.byHash)+1 > MEMPOOL_MAX_TRANSACTIONS || mp.size+e.size > MEMPOOL_MAX_SIZE {
+		var lowest *mempoolEntry
+		for sender, queue := range mp.senders {
+			if sender == e.transaction.senderBlockchainAddress {
+				continue
+			}
+			tail := queue[len(queue)-1]
+			if lowest == nil || higherFeeRate(lowest.transaction, lowest.size, tail.transaction, tail.size) {
+				lowest = tail
+			}
+		}
+		if lowest == nil || !higherFeeRate(e.transaction, e.size, lowest.transaction, lowest.size) {
+			return false
+		}
+		sender := lowest.transaction.senderBlockchainAddress
+		log.Printf("Mempool: evicting transaction %x from %s", lowest.hash, sender)
+		mp.dropTailLocked(sender, len(mp.senders[sender])-1)
+	}
+	return true
+}
+
+// Expire mengeluarkan transaksi yang sudah lebih lama dari MEMPOOL_EXPIRY di
+// pool beserta transaksi sender yang bergantung padanya.
+func (mp *Mempool) Expire() {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	mp.expireLocked()
+}
+
+func (mp *Mempool) expireLocked() {
+	deadline := time.Now().Add(-MEMPOOL_EXPIRY)
+	for sender, queue := range mp.senders {
+		for i, e := range queue {
+			if e.added.Before(deadline) {
+				log.Printf("Mempool: %d transactions from %s expired", len(queue)-i, sender)
+				mp.dropTailLocked(sender, i)
+				break
+			}
+		}
+	}
+}
+
+// Remove mengeluarkan tepat transaksi yang sudah masuk block baru. Transaksi
+// lain milik sender yang sama tetap di pool selama nonce-nya masih
+// menyambung dengan nonce confirmed.
+func (mp *Mempool) Remove(transactions []*Transaction) {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+
+	affected := make(map[string]bool)
+	for _, t := range transactions {
+		if t.senderBlockchainAddress == MINING_SENDER {
+			continue
+		}
+		e, ok := mp.byHash[t.Hash()]
+		if !ok {
+			// Transaksi lain dengan nonce yang sama dari sender ini sudah
+			// masuk block, jadi antrean sender perlu diperiksa ulang
+			affected[t.senderBlockchainAddress] = true
+			continue
+		}
+		sender := e.transaction.senderBlockchainAddress
+		queue := mp.senders[sender]
+		for i, q := range queue {
+			if q == e {
+				queue = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+		delete(mp.byHash, e.hash)
+		mp.size -= e.size
+		mp.journalRemoveLocked(e)
+		if len(queue) == 0 {
+			delete(mp.senders, sender)
+		} else {
+			mp.senders[sender] = queue
+		}
+		affected[sender] = true
+	}
+	for sender := range affected {
+		mp.revalidateLocked(sender)
+	}
+
+	if mp.journal != nil && mp.journal.needsCompaction(len(mp.byHash)) {
+		mp.compactJournalLocked()
+	}
+}
+
+// revalidateLocked membuang transaksi sender yang nonce-nya sudah dipakai
+// di chain (misalnya transaksi pengganti ketika transaksi aslinya yang
+// ditambang), lalu transaksi yang tidak lagi menyambung dengan nonce
+// confirmed.
+func (mp *Mempool) revalidateLocked(sender string) {
+	confirmed := mp.chain.ConfirmedNonce(sender)
+	queue := mp.senders[sender]
+	stale := 0
+	for stale < len(queue) && queue[stale].transaction.nonce < confirmed {
+		stale += 1
+	}
+	if stale > 0 {
+		log.Printf("Mempool: dropping %d transactions from %s with confirmed nonces", stale, sender)
+		mp.dropHeadLocked(sender, stale)
+	}
+
+	for i, e := range mp.senders[sender] {
+		if e.transaction.nonce != confirmed+uint64(i) {
+			log.Printf("Mempool: dropping %d stale transactions from %s", len(mp.senders[sender])-i, sender)
+			mp.dropTailLocked(sender, i)
+			return
+		}
+	}
+}
+
+// Restore memuat transaksi dari journal ke pool dan sejak itu mencatat
+// setiap perubahan pool ke journal. Setiap transaksi diperiksa ulang
+// terhadap state chain saat ini; yang sudah tidak valid (sudah masuk block,
+// saldo tidak cukup, kedaluwarsa) dibuang dan dicatat di log.
+func (mp *Mempool) Restore(journal *MempoolJournal) error {
+	pending, err := journal.Load()
+	if err != nil {
+		return err
+	}
+
+	// Transaksi pengganti tercatat setelah nonce yang lebih tinggi, jadi
+	// urutkan nonce supaya antrean setiap sender bisa dimasukkan ulang
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Transaction.nonce < pending[j].Transaction.nonce
+	})
+
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	mp.journal = nil
+	deadline := time.Now().Add(-MEMPOOL_EXPIRY)
+	restored, dropped := 0, 0
+	for _, jt := range pending {
+		t := jt.Transaction
+		err := ErrMempoolExpired
+		if !jt.Added.Before(deadline) {
+			err = mp.addLocked(t, jt.Added)
+		}
+		if err != nil {
+			h := t.Hash()
+			log.Printf("Mempool: dropping journaled transaction %x from %s: %v", h, t.senderBlockchainAddress, err)
+			dropped += 1
+			continue
+		}
+		restored += 1
+	}
+	mp.journal = journal
+	mp.compactJournalLocked()
+	log.Printf("Mempool: restored %d transactions from journal, %d dropped", restored, dropped)
+	return nil
+}
+
+func (mp *Mempool) journalAddLocked(e *mempoolEntry) {
+	if mp.journal == nil {
+		return
+	}
+	if err := mp.journal.Add(e.transaction, e.added); err != nil {
+		log.Printf("ERROR: Failed to journal transaction %x: %v", e.hash, err)
+	}
+}
+
+func (mp *Mempool) journalRemoveLocked(e *mempoolEntry) {
+	if mp.journal == nil {
+		return
+	}
+	if err := mp.journal.Remove(e.hash); err != nil {
+		log.Printf("ERROR: Failed to journal removal of %x: %v", e.hash, err)
+	}
+}
+
+// compactJournalLocked menulis ulang journal hanya berisi isi pool saat ini.
+func (mp *Mempool) compactJournalLocked() {
+	entries := make([]*mempoolEntry, 0, len(mp.byHash))
+	for _, e := range mp.byHash {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	pending := make([]*JournaledTransaction, 0, len(entries))
+	for _, e := range entries {
+		pending = append(pending, &JournaledTransaction{Transaction: e.transaction, Added: e.added})
+	}
+	if err := mp.journal.Rewrite(pending); err != nil {
+		log.Printf("ERROR: Failed to compact mempool journal: %v", err)
+	}
+}
+
+// Clear mengosongkan pool dan mengembalikan isinya sesuai urutan masuk.
+func (mp *Mempool) Clear() []*Transaction {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	transactions := mp.transactionsLocked()
+	for sender := range mp.senders {
+		mp.dropTailLocked(sender, 0)
+	}
+	return transactions
+}
+
+// Transactions mengembalikan isi pool sesuai urutan masuk.
+func (mp *Mempool) Transactions() []*Transaction {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	mp.expireLocked()
+	return mp.transactionsLocked()
+}
+
+func (mp *Mempool) transactionsLocked() []*Transaction {
+	entries := make([]*mempoolEntry, 0, len(mp.byHash))
+	for _, e := range mp.byHash {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	transactions := make([]*Transaction, 0, len(entries))
+	for _, e := range entries {
+		transactions = append(transactions, e.transaction)
+	}
+	return transactions
+}
+
+// Pending mengembalikan salinan antrean transaksi setiap sender, masing-masing
+// berurutan nonce mulai dari nonce confirmed. Sender diurutkan menurut
+// transaksi pertamanya masuk pool.
+func (mp *Mempool) Pending() [][]*Transaction {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	mp.expireLocked()
+
+	queues := make([][]*mempoolEntry, 0, len(mp.senders))
+	for _, queue := range mp.senders {
+		queues = append(queues, queue)
+	}
+	sort.Slice(queues, func(i, j int) bool { return queues[i][0].seq < queues[j][0].seq })
+
+	pending := make([][]*Transaction, 0, len(queues))
+	for _, queue := range queues {
+		transactions := make([]*Transaction, 0, len(queue))
+		for _, e := range queue {
+			c := *e.transaction
+			transactions = append(transactions, &c)
+		}
+		pending = append(pending, transactions)
+	}
+	return pending
+}
+
+// Len adalah jumlah transaksi di pool.
+func (mp *Mempool) Len() int {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	return len(mp.byHash)
+}
+
+// Size adalah total ukuran transaksi di pool dalam byte.
+func (mp *Mempool) Size() int {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	return mp.size
+}
+
+// PendingCount adalah jumlah transaksi sender di pool.
+func (mp *Mempool) PendingCount(sender string) int {
+	mp.mux.Lock()
+	defer mp.mux.Unlock()
+	return len(mp.senders[sender])
+}
@@ -0,0 +1,298 @@
+package block
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testChain adalah state chain palsu untuk menguji Mempool tanpa Blockchain.
+type testChain struct {
+	balances map[string]Amount
+	nonces   map[string]uint64
+}
+
+func newTestChain() *testChain {
+	return &testChain{balances: make(map[string]Amount), nonces: make(map[string]uint64)}
+}
+
+func (c *testChain) VerifyTransaction(t *Transaction) error     { return nil }
+func (c *testChain) CalculateTotalAmount(address string) Amount { return c.balances[address] }
+func (c *testChain) ImmatureAmount(address string) Amount       { return 0 }
+func (c *testChain) ConfirmedNonce(address string) uint64       { return c.nonces[address] }
+func (c *testChain) confirm(transactions ...*Transaction) {
+	for _, t := range transactions {
+		c.nonces[t.senderBlockchainAddress] += 1
+		c.balances[t.senderBlockchainAddress] -= t.value + t.fee
+	}
+}
+
+func newTestMempool(t *testing.T, balance Amount, senders ...string) (*Mempool, *testChain) {
+	t.Helper()
+	chain := newTestChain()
+	for _, s := range senders {
+		chain.balances[s] = balance
+	}
+	return NewMempool(chain), chain
+}
+
+func mustAdd(t *testing.T, mp *Mempool, tx *Transaction) {
+	t.Helper()
+	if err := mp.Add(tx); err != nil {
+		t.Fatalf("Add(nonce %d): %v", tx.nonce, err)
+	}
+}
+
+func poolNonces(mp *Mempool, sender string) []uint64 {
+	var nonces []uint64
+	for _, t := range mp.Transactions() {
+		if t.senderBlockchainAddress == sender {
+			nonces = append(nonces, t.nonce)
+		}
+	}
+	return nonces
+}
+
+func equalNonces(a []uint64, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMempoolRemove(t *testing.T) {
+	tests := []struct {
+		name string
+		// mined adalah nonce yang masuk block, replaced menandai bahwa
+		// transaksi di block adalah transaksi asli sementara pool berisi
+		// penggantinya
+		mined    []uint64
+		replaced bool
+		want     []uint64
+	}{
+		{name: "head confirmed", mined: []uint64{0}, want: []uint64{1, 2}},
+		{name: "two confirmed", mined: []uint64{0, 1}, want: []uint64{2}},
+		{name: "all confirmed", mined: []uint64{0, 1, 2}, want: nil},
+		{name: "replaced head mined", mined: []uint64{0}, replaced: true, want: []uint64{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, chain := newTestMempool(t, 100*AMOUNT_UNIT, "alice")
+			originals := make([]*Transaction, 3)
+			for i := range originals {
+				originals[i] = NewTransaction("alice", "bob", AMOUNT_UNIT, 10, uint64(i))
+				mustAdd(t, mp, originals[i])
+			}
+			if tt.replaced {
+				mustAdd(t, mp, NewTransaction("alice", "bob", AMOUNT_UNIT, 20, 0))
+			}
+
+			var block []*Transaction
+			for _, n := range tt.mined {
+				block = append(block, originals[n])
+			}
+			chain.confirm(block...)
+			mp.Remove(block)
+
+			if got := poolNonces(mp, "alice"); !equalNonces(got, tt.want) {
+				t.Errorf("pool nonces = %v, want %v", got, tt.want)
+			}
+			if got, want := mp.Size(), TransactionsSize(mp.Transactions()); got != want {
+				t.Errorf("Size() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestMempoolReplaceByFee(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   Amount
+		fee     Amount
+		wantErr bool
+	}{
+		{name: "same fee", value: AMOUNT_UNIT, fee: 1000, wantErr: true},
+		{name: "bump below minimum", value: AMOUNT_UNIT, fee: 1099, wantErr: true},
+		{name: "minimum bump", value: AMOUNT_UNIT, fee: 1100},
+		{name: "overspend", value: 10 * AMOUNT_UNIT, fee: 2000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, _ := newTestMempool(t, 3*AMOUNT_UNIT, "alice")
+			original := NewTransaction("alice", "bob", AMOUNT_UNIT, 1000, 0)
+			mustAdd(t, mp, original)
+			mustAdd(t, mp, NewTransaction("alice", "bob", AMOUNT_UNIT, 1000, 1))
+
+			replacement := NewTransaction("alice", "carol", tt.value, tt.fee, 0)
+			err := mp.Add(replacement)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Add() succeeded, want error")
+				}
+				if got := mp.Transactions()[0].Hash(); got != original.Hash() {
+					t.Errorf("original transaction was replaced")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Add(): %v", err)
+			}
+			pool := mp.Transactions()
+			if len(pool) != 2 || pool[0].Hash() != replacement.Hash() || pool[1].nonce != 1 {
+				t.Errorf("pool = %v, want replacement followed by nonce 1", pool)
+			}
+		})
+	}
+}
+
+func TestMempoolAddErrors(t *testing.T) {
+	mp, _ := newTestMempool(t, AMOUNT_UNIT, "alice")
+	mustAdd(t, mp, NewTransaction("alice", "bob", AMOUNT_UNIT/2, 0, 0))
+
+	var nonceErr *NonceError
+	var balanceErr *InsufficientBalanceError
+	tests := []struct {
+		name  string
+		tx    *Transaction
+		check func(error) bool
+	}{
+		{"duplicate", NewTransaction("alice", "bob", AMOUNT_UNIT/2, 0, 0),
+			func(err error) bool { return errors.Is(err, ErrDuplicateTransaction) }},
+		{"nonce gap", NewTransaction("alice", "bob", 1, 0, 2),
+			func(err error) bool { return errors.As(err, &nonceErr) }},
+		{"balance", NewTransaction("alice", "bob", AMOUNT_UNIT/2, 1, 1),
+			func(err error) bool { return errors.As(err, &balanceErr) }},
+		{"zero value", NewTransaction("alice", "bob", 0, 0, 1),
+			func(err error) bool { return errors.Is(err, ErrInvalidAmount) }},
+		{"coinbase", NewTransaction(MINING_SENDER, "alice", 1, 0, 0),
+			func(err error) bool { return errors.Is(err, ErrCoinbaseTransaction) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := mp.Add(tt.tx); !tt.check(err) {
+				t.Errorf("Add() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMempoolExpire(t *testing.T) {
+	mp, _ := newTestMempool(t, 100*AMOUNT_UNIT, "alice", "bob")
+	for n := uint64(0); n < 3; n++ {
+		mustAdd(t, mp, NewTransaction("alice", "carol", 1, 0, n))
+	}
+	mustAdd(t, mp, NewTransaction("bob", "carol", 1, 0, 0))
+	mp.senders["alice"][1].added = time.Now().Add(-MEMPOOL_EXPIRY - time.Minute)
+
+	mp.Expire()
+
+	if got := poolNonces(mp, "alice"); !equalNonces(got, []uint64{0}) {
+		t.Errorf("alice nonces = %v, want [0]", got)
+	}
+	if got := poolNonces(mp, "bob"); !equalNonces(got, []uint64{0}) {
+		t.Errorf("bob nonces = %v, want [0]", got)
+	}
+}
+
+// fillTestMempool mengisi pool sampai MEMPOOL_MAX_TRANSACTIONS dengan
+// antrean penuh dari sender yang dikembalikan; fee sender ke-i adalah fee(i).
+func fillTestMempool(t *testing.T, fee func(i int) Amount) (*Mempool, []string) {
+	t.Helper()
+	senders := make([]string, MEMPOOL_MAX_TRANSACTIONS/MEMPOOL_MAX_PER_SENDER)
+	for i := range senders {
+		senders[i] = fmt.Sprintf("sender%02d", i)
+	}
+	mp, chain := newTestMempool(t, 1000*AMOUNT_UNIT, senders...)
+	chain.balances["newcomer"] = 1000 * AMOUNT_UNIT
+	for i, sender := range senders {
+		for n := uint64(0); n < MEMPOOL_MAX_PER_SENDER; n++ {
+			mustAdd(t, mp, NewTransaction(sender, "recipient", 1, fee(i), n))
+		}
+	}
+	if mp.Len() != MEMPOOL_MAX_TRANSACTIONS {
+		t.Fatalf("filled pool has %d transactions, want %d", mp.Len(), MEMPOOL_MAX_TRANSACTIONS)
+	}
+	return mp, senders
+}
+
+func TestMempoolLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		// fee adalah fee tiap transaksi sender ke-i saat pool diisi
+		fee func(i int) Amount
+		// prepare mengubah pool setelah diisi, sebelum tx ditambahkan
+		prepare func(mp *Mempool, senders []string)
+		tx      *Transaction
+		wantErr error
+		// wantNonces adalah nonce yang tersisa untuk sender tertentu
+		wantNonces map[int]int
+	}{
+		{
+			name:       "sender cap",
+			fee:        func(i int) Amount { return 1 },
+			tx:         NewTransaction("sender00", "recipient", 1, 1000, MEMPOOL_MAX_PER_SENDER),
+			wantErr:    ErrMempoolSenderLimit,
+			wantNonces: map[int]int{0: MEMPOOL_MAX_PER_SENDER},
+		},
+		{
+			name:    "full pool keeps equal fee rate",
+			fee:     func(i int) Amount { return 10 },
+			tx:      NewTransaction("newcomer", "recipient", 1, 10, 0),
+			wantErr: ErrMempoolFull,
+		},
+		{
+			name: "full pool evicts lowest fee rate",
+			fee: func(i int) Amount {
+				if i == 7 {
+					return 5
+				}
+				return 10
+			},
+			tx:         NewTransaction("newcomer", "recipient", 1, 10, 0),
+			wantNonces: map[int]int{7: MEMPOOL_MAX_PER_SENDER - 1, 6: MEMPOOL_MAX_PER_SENDER, 8: MEMPOOL_MAX_PER_SENDER},
+		},
+		{
+			name: "expired transactions free room",
+			fee:  func(i int) Amount { return 10 },
+			prepare: func(mp *Mempool, senders []string) {
+				mp.senders[senders[3]][90].added = time.Now().Add(-MEMPOOL_EXPIRY - time.Minute)
+			},
+			tx:         NewTransaction("newcomer", "recipient", 1, 0, 0),
+			wantNonces: map[int]int{3: 90, 4: MEMPOOL_MAX_PER_SENDER},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, senders := fillTestMempool(t, tt.fee)
+			if tt.prepare != nil {
+				tt.prepare(mp, senders)
+			}
+			before := mp.Len()
+
+			err := mp.Add(tt.tx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+			if mp.Len() > MEMPOOL_MAX_TRANSACTIONS {
+				t.Errorf("pool has %d transactions, above the cap %d", mp.Len(), MEMPOOL_MAX_TRANSACTIONS)
+			}
+			if tt.wantErr != nil && mp.Len() != before {
+				t.Errorf("rejected Add changed the pool size from %d to %d", before, mp.Len())
+			}
+			if tt.wantErr == nil && mp.PendingCount(tt.tx.senderBlockchainAddress) != 1 {
+				t.Error("accepted transaction is not in the pool")
+			}
+			for i, want := range tt.wantNonces {
+				if got := mp.PendingCount(senders[i]); got != want {
+					t.Errorf("%s has %d pending transactions, want %d", senders[i], got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package block
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	MERKLE_POSITION_LEFT  = "left"
+	MERKLE_POSITION_RIGHT = "right"
+)
+
+// MerkleProofStep adalah satu sibling hash dalam jalur dari transaksi ke root.
+// Position menunjukkan di sisi mana sibling berada saat di-hash bersama.
+type MerkleProofStep struct {
+	Hash     [32]byte
+	Position string
+}
+
+func (s *MerkleProofStep) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Hash     string `json:"hash"`
+		Position string `json:"position"`
+	}{
+		Hash:     hex.EncodeToString(s.Hash[:]),
+		Position: s.Position,
+	})
+}
+
+func (s *MerkleProofStep) UnmarshalJSON(data []byte) error {
+	var hash string
+	v := &struct {
+		Hash     *string `json:"hash"`
+		Position *string `json:"position"`
+	}{
+		Hash:     &hash,
+		Position: &s.Position,
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	h, err := hex.DecodeString(hash)
+	if err != nil || len(h) != 32 {
+		return errors.New("invalid merkle proof hash")
+	}
+	copy(s.Hash[:], h)
+	return nil
+}
+
+func merkleParent(left [32]byte, right [32]byte) [32]byte {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
+
+// merkleLevels membangun semua level tree dari daun (hash transaksi) sampai
+// root. Level dengan jumlah ganjil menduplikasi hash terakhirnya.
+func merkleLevels(transactions []*Transaction) [][][32]byte {
+	level := make([][32]byte, 0, len(transactions))
+	for _, t := range transactions {
+		level = append(level, t.Hash())
+	}
+	levels := [][][32]byte{level}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+			levels[len(levels)-1] = level
+		}
+		next := make([][32]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, merkleParent(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// MerkleRoot menghitung root dari daftar transaksi. Block tanpa transaksi
+// memiliki root berisi nol.
+func MerkleRoot(transactions []*Transaction) [32]byte {
+	if len(transactions) == 0 {
+		return [32]byte{}
+	}
+	levels := merkleLevels(transactions)
+	return levels[len(levels)-1][0]
+}
+
+// MerkleProof mengembalikan sibling hash yang dibutuhkan untuk membuktikan
+// transaksi ke-index termasuk dalam root.
+func MerkleProof(transactions []*Transaction, index int) []*MerkleProofStep {
+	if index < 0 || index >= len(transactions) {
+		return nil
+	}
+	levels := merkleLevels(transactions)
+	proof := make([]*MerkleProofStep, 0, len(levels)-1)
+	for _, level := range levels[:len(levels)-1] {
+		if index%2 == 0 {
+			proof = append(proof, &MerkleProofStep{level[index+1], MERKLE_POSITION_RIGHT})
+		} else {
+			proof = append(proof, &MerkleProofStep{level[index-1], MERKLE_POSITION_LEFT})
+		}
+		index /= 2
+	}
+	return proof
+}
+
+func VerifyMerkleProof(transactionHash [32]byte, merkleRoot [32]byte, proof []*MerkleProofStep) bool {
+	h := transactionHash
+	for _, step := range proof {
+		switch step.Position {
+		case MERKLE_POSITION_LEFT:
+			h = merkleParent(step.Hash, h)
+		case MERKLE_POSITION_RIGHT:
+			h = merkleParent(h, step.Hash)
+		default:
+			return false
+		}
+	}
+	return h == merkleRoot
+}
+
+type MerkleProofResponse struct {
+	BlockHash       [32]byte
+	BlockIndex      int
+	MerkleRoot      [32]byte
+	TransactionHash [32]byte
+	Index           int
+	Proof           []*MerkleProofStep
+}
+
+func (mr *MerkleProofResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		BlockHash       string             `json:"block_hash"`
+		BlockIndex      int                `json:"block_index"`
+		MerkleRoot      string             `json:"merkle_root"`
+		TransactionHash string             `json:"transaction_hash"`
+		Index           int                `json:"index"`
+		Proof           []*MerkleProofStep `json:"proof"`
+	}{
+		BlockHash:       hex.EncodeToString(mr.BlockHash[:]),
+		BlockIndex:      mr.BlockIndex,
+		MerkleRoot:      hex.EncodeToString(mr.MerkleRoot[:]),
+		TransactionHash: hex.EncodeToString(mr.TransactionHash[:]),
+		Index:           mr.Index,
+		Proof:           mr.Proof,
+	})
+}
+
+// TransactionProof mencari transaksi dengan hash tertentu di chain dan
+// membuat Merkle inclusion proof-nya.
+func (bc *Blockchain) TransactionProof(transactionHash [32]byte) (*MerkleProofResponse, bool) {
+	for i, b := range bc.Chain() {
+		for j, t := range b.transactions {
+			if t.Hash() != transactionHash {
+				continue
+			}
+			return &MerkleProofResponse{
+				BlockHash:       b.Hash(),
+				BlockIndex:      i,
+				MerkleRoot:      b.merkleRoot,
+				TransactionHash: transactionHash,
+				Index:           j,
+				Proof:           MerkleProof(b.transactions, j),
+			}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,121 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func testTransactions(n int) []*Transaction {
+	transactions := make([]*Transaction, n)
+	for i := range transactions {
+		transactions[i] = NewTransaction("sender", "recipient", Amount(i+1), 0, uint64(i))
+	}
+	return transactions
+}
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		t.Run(fmt.Sprintf("%d transactions", n), func(t *testing.T) {
+			transactions := testTransactions(n)
+			root := MerkleRoot(transactions)
+			for i, tx := range transactions {
+				proof := MerkleProof(transactions, i)
+				if !VerifyMerkleProof(tx.Hash(), root, proof) {
+					t.Fatalf("proof for index %d does not verify", i)
+				}
+
+				other := NewTransaction("sender", "recipient", 1000, 0, 0)
+				if VerifyMerkleProof(other.Hash(), root, proof) {
+					t.Errorf("proof for index %d verifies another transaction", i)
+				}
+				if len(proof) > 0 {
+					tampered := append([]*MerkleProofStep(nil), proof...)
+					step := *tampered[0]
+					step.Hash[0] ^= 1
+					tampered[0] = &step
+					if VerifyMerkleProof(tx.Hash(), root, tampered) {
+						t.Errorf("tampered proof for index %d verifies", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	if root := MerkleRoot(nil); root != [32]byte{} {
+		t.Errorf("empty root = %x, want zero", root)
+	}
+	single := testTransactions(1)
+	if MerkleRoot(single) != single[0].Hash() {
+		t.Error("root of one transaction is not its hash")
+	}
+
+	transactions := testTransactions(4)
+	root := MerkleRoot(transactions)
+	swapped := []*Transaction{transactions[1], transactions[0], transactions[2], transactions[3]}
+	if MerkleRoot(swapped) == root {
+		t.Error("root does not depend on transaction order")
+	}
+	changed := append(testTransactions(3), NewTransaction("sender", "recipient", 5, 1, 3))
+	if MerkleRoot(changed) == root {
+		t.Error("root does not depend on transaction contents")
+	}
+}
+
+func TestMerkleProofInvalid(t *testing.T) {
+	transactions := testTransactions(3)
+	for _, index := range []int{-1, 3} {
+		if proof := MerkleProof(transactions, index); proof != nil {
+			t.Errorf("MerkleProof(%d) = %v, want nil", index, proof)
+		}
+	}
+
+	root := MerkleRoot(transactions)
+	proof := MerkleProof(transactions, 0)
+	bad := &MerkleProofStep{Hash: proof[0].Hash, Position: "middle"}
+	if VerifyMerkleProof(transactions[0].Hash(), root, append([]*MerkleProofStep{bad}, proof[1:]...)) {
+		t.Error("proof with unknown position verifies")
+	}
+}
+
+func TestMerkleProofJSON(t *testing.T) {
+	transactions := testTransactions(5)
+	proof := MerkleProof(transactions, 3)
+	data, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*MerkleProofStep
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyMerkleProof(transactions[3].Hash(), MerkleRoot(transactions), decoded) {
+		t.Error("decoded proof does not verify")
+	}
+
+	if err := json.Unmarshal([]byte(`[{"hash":"abcd","position":"left"}]`), &decoded); err == nil {
+		t.Error("Unmarshal accepted a short proof hash")
+	}
+}
+
+func TestTransactionProof(t *testing.T) {
+	bc, signer, sender := fundedTestChain(t)
+	tx := signedTransaction(t, signer, sender, "recipient", 1, 0, 0)
+	bc.appendBlock(nextTestBlock(bc.chain, "miner", tx))
+
+	proof, ok := bc.TransactionProof(tx.Hash())
+	if !ok {
+		t.Fatal("TransactionProof did not find the transaction")
+	}
+	if proof.BlockIndex != 2 || proof.Index != 1 {
+		t.Errorf("proof location = block %d index %d, want block 2 index 1", proof.BlockIndex, proof.Index)
+	}
+	if !VerifyMerkleProof(proof.TransactionHash, proof.MerkleRoot, proof.Proof) {
+		t.Error("proof does not verify")
+	}
+	if _, ok := bc.TransactionProof([32]byte{1}); ok {
+		t.Error("TransactionProof found an unknown transaction")
+	}
+}
@@ -0,0 +1,175 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Setiap sekian nonce ProofOfWork memeriksa apakah pencarian dibatalkan.
+const MINING_CANCEL_CHECK_INTERVAL = 1 << 10
+
+// ProofOfWork mencari nonce untuk block baru berisi transactions di atas
+// block previousHash. Pencarian berhenti dengan ctx.Err() bila ctx dibatalkan.
+func ProofOfWork(ctx context.Context, previousHash [32]byte, difficulty int, transactions []*Transaction) (*Block, error) {
+	guessBlock := CreateNewBlock(0, previousHash, difficulty, transactions)
+	for leadingZeroBits(guessBlock.Hash()) < guessBlock.difficulty {
+		guessBlock.nonce += 1
+		if guessBlock.nonce%MINING_CANCEL_CHECK_INTERVAL == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return guessBlock, nil
+}
+
+// changes mengembalikan channel yang ditutup pada perubahan tip chain atau
+// mempool berikutnya.
+func (bc *Blockchain) changes() <-chan struct{} {
+	bc.muxChanged.Lock()
+	defer bc.muxChanged.Unlock()
+	return bc.changed
+}
+
+// notifyChange membangunkan semua pencarian nonce yang sedang berjalan agar
+// menyusun ulang block di atas tip dan mempool terbaru.
+func (bc *Blockchain) notifyChange() {
+	bc.muxChanged.Lock()
+	defer bc.muxChanged.Unlock()
+	close(bc.changed)
+	bc.changed = make(chan struct{})
+}
+
+// mineBlock menyusun satu block dan mencari nonce-nya tanpa memegang bc.mux.
+// Mengembalikan false bila pencarian dibatalkan karena ctx selesai atau tip
+// berubah, dan bila followPool juga karena isi mempool berubah; pemanggil
+// bisa langsung mencoba lagi dengan template baru.
+func (bc *Blockchain) mineBlock(ctx context.Context, followPool bool) bool {
+	bc.mux.Lock()
+	height := len(bc.chain)
+	previousHash := bc.chain[height-1].Hash()
+	difficulty := NextDifficulty(bc.chain)
+	transactions := bc.BlockTemplate()
+	changed := bc.changes()
+	bc.mux.Unlock()
+
+	roundCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		for {
+			select {
+			case <-changed:
+				// Ambil channel berikutnya sebelum memeriksa tip supaya
+				// perubahan tip sesudahnya tidak terlewat
+				changed = bc.changes()
+				if followPool || bc.LastBlock().Hash() != previousHash {
+					cancel()
+					return
+				}
+			case <-roundCtx.Done():
+				return
+			}
+		}
+	}()
+
+	block, err := ProofOfWork(roundCtx, previousHash, difficulty, transactions)
+	if err != nil {
+		log.Printf("action=mining, status=restart, height=%d", height)
+		return false
+	}
+
+	bc.mux.Lock()
+	if bc.chain[len(bc.chain)-1].Hash() != previousHash {
+		bc.mux.Unlock()
+		log.Printf("action=mining, status=stale, height=%d", height)
+		return false
+	}
+	if err := bc.appendBlock(block); err != nil {
+		bc.mux.Unlock()
+		log.Printf("ERROR: Failed to append mined block at height %d: %v", height, err)
+		return false
+	}
+	bc.mux.Unlock()
+	log.Printf("action=mining, status=success, difficulty=%d, transactions=%d, coinbase=%s",
+		block.difficulty, len(transactions), transactions[0].value)
+
+	bc.announceBlock()
+	return true
+}
+
+// Mining menambang satu block dan menunggu sampai berhasil atau ctx selesai.
+// Berbeda dengan miner background, pencarian hanya diulang bila tip berubah;
+// transaksi yang terus masuk ke mempool menunggu block berikutnya supaya
+// permintaan ini tidak berputar tanpa akhir.
+func (bc *Blockchain) Mining(ctx context.Context) bool {
+	for ctx.Err() == nil {
+		if bc.mineBlock(ctx, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartMining menjalankan miner di background bila belum berjalan. Miner
+// langsung mulai block berikutnya; jarak antar block diatur oleh difficulty
+// (lihat NextDifficulty), bukan oleh jeda.
+func (bc *Blockchain) StartMining() {
+	bc.muxMiner.Lock()
+	defer bc.muxMiner.Unlock()
+	if bc.stopMiner != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	bc.stopMiner = func() {
+		cancel()
+		<-done
+	}
+	go func() {
+		defer close(done)
+		log.Println("action=mining, status=started")
+		for ctx.Err() == nil {
+			bc.mineBlock(ctx, true)
+		}
+		log.Println("action=mining, status=stopped")
+	}()
+}
+
+// StopMining menghentikan miner background dan menunggu sampai berhenti.
+func (bc *Blockchain) StopMining() {
+	bc.muxMiner.Lock()
+	defer bc.muxMiner.Unlock()
+	if bc.stopMiner != nil {
+		bc.stopMiner()
+		bc.stopMiner = nil
+	}
+}
+
+// announceBlock meminta neighbors menjalankan consensus setelah block baru.
+func (bc *Blockchain) announceBlock() {
+	for _, n := range bc.neighbors {
+		endpoint := fmt.Sprintf("http://%s/consensus", n)
+		client := &http.Client{}
+
+		// Membuat permintaan PUT
+		req, err := http.NewRequest("PUT", endpoint, nil)
+		if err != nil {
+			log.Printf("Error creating request to %s: %v", n, err)
+			continue
+		}
+
+		// Mengirim permintaan
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Printf("Error sending request to %s: %v", n, err)
+			continue
+		}
+		resp.Body.Close()
+
+		// Mencatat status respons
+		log.Printf("Response from %s: %s", n, resp.Status)
+	}
+}
@@ -0,0 +1,185 @@
+package block
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMiningConcurrentAccess menjalankan miner bersamaan dengan pembaca chain
+// dan transaksi baru; jalankan dengan -race.
+func TestMiningConcurrentAccess(t *testing.T) {
+	signer, sender := newTestSigner(t)
+	bc := NewBlockchain(sender, 0, nil, 0)
+
+	const blocks = 3
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for mined := 0; mined < blocks; {
+			if bc.mineBlock(context.Background(), true) {
+				mined += 1
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			bc.CalculateTotalAmount(sender)
+			bc.ImmatureAmount(sender)
+			bc.NextNonce(sender)
+			bc.Tip()
+			bc.Headers(0, SYNC_HEADERS_BATCH_SIZE)
+			bc.Supply()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var nonce uint64
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			tx := signedTransaction(t, signer, sender, "recipient", 1, 0, nonce)
+			if bc.AddTransaction(sender, "recipient", 1, 0, nonce, tx.senderPublicKey, tx.signature) == nil {
+				nonce += 1
+			}
+		}
+	}()
+	<-done
+	wg.Wait()
+
+	if got := len(bc.Chain()); got != blocks+1 {
+		t.Errorf("chain length = %d, want %d", got, blocks+1)
+	}
+}
+
+// TestMineBlockRestart memeriksa kapan sebuah putaran pencarian nonce
+// dibatalkan. Difficulty dibuat mustahil supaya putaran hanya berakhir karena
+// dibatalkan.
+func TestMineBlockRestart(t *testing.T) {
+	tests := []struct {
+		name        string
+		followPool  bool
+		tipChange   bool
+		wantRestart bool
+	}{
+		{name: "pool change, background miner", followPool: true, wantRestart: true},
+		{name: "pool change, synchronous mining", followPool: false, wantRestart: false},
+		{name: "tip change, synchronous mining", followPool: false, tipChange: true, wantRestart: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, miner := newTestSigner(t)
+			bc := NewBlockchain(miner, 0, nil, 0)
+			bc.chain[0].difficulty = MAX_DIFFICULTY
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			result := make(chan bool, 1)
+			go func() { result <- bc.mineBlock(ctx, tt.followPool) }()
+			// Beri waktu mineBlock mengambil channel perubahan
+			time.Sleep(50 * time.Millisecond)
+
+			if tt.tipChange {
+				bc.mux.Lock()
+				bc.appendBlock(nextTestBlock(bc.chain, miner))
+				bc.mux.Unlock()
+			} else {
+				bc.notifyChange()
+			}
+
+			select {
+			case mined := <-result:
+				if !tt.wantRestart {
+					t.Fatalf("mineBlock() = %v after change, want to keep mining", mined)
+				}
+			case <-time.After(500 * time.Millisecond):
+				if tt.wantRestart {
+					t.Fatal("mineBlock() kept mining after change, want restart")
+				}
+				cancel()
+				if mined := <-result; mined {
+					t.Fatal("mineBlock() = true after cancel")
+				}
+			}
+		})
+	}
+}
+
+func TestMining(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{name: "mined", ctx: context.Background(), want: true},
+		{name: "cancelled", ctx: cancelled, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, miner := newTestSigner(t)
+			bc := NewBlockchain(miner, 0, nil, 0)
+
+			if got := bc.Mining(tt.ctx); got != tt.want {
+				t.Fatalf("Mining() = %v, want %v", got, tt.want)
+			}
+			wantLength := 1
+			if tt.want {
+				wantLength = 2
+			}
+			if got := len(bc.Chain()); got != wantLength {
+				t.Errorf("chain length = %d, want %d", got, wantLength)
+			}
+		})
+	}
+}
+
+// TestMinerRaisesDifficulty menjalankan miner background sampai retarget
+// pertama. Block di lingkungan test ditemukan jauh lebih cepat dari
+// TARGET_BLOCK_TIME_SEC, jadi difficulty harus naik.
+func TestMinerRaisesDifficulty(t *testing.T) {
+	_, miner := newTestSigner(t)
+	bc := NewBlockchain(miner, 0, nil, 0)
+	height := 2 * DIFFICULTY_ADJUSTMENT_INTERVAL
+
+	bc.StartMining()
+	deadline := time.After(60 * time.Second)
+	for {
+		// Ambil channel sebelum memeriksa panjang chain supaya block
+		// terakhir tidak terlewat
+		changed := bc.changes()
+		if len(bc.Chain()) > height {
+			break
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			bc.StopMining()
+			t.Fatalf("mined %d blocks in 60s, want %d", len(bc.Chain())-1, height)
+		}
+	}
+	bc.StopMining()
+
+	chain := bc.Chain()
+	if got, want := chain[height].difficulty, INITIAL_DIFFICULTY+MAX_DIFFICULTY_STEP; got != want {
+		t.Errorf("difficulty at height %d = %d, want %d", height, got, want)
+	}
+	if !bc.ValidChain(chain) {
+		t.Error("mined chain is invalid")
+	}
+}
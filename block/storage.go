@@ -0,0 +1,262 @@
+package block
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	STORAGE_LOG_FILE   = "blocks.log"
+	STORAGE_INDEX_FILE = "blocks.idx"
+
+	// length (4 byte) + crc32 (4 byte) sebelum payload blok
+	storageRecordHeaderSize = 8
+	storageIndexEntrySize   = 8
+	storageMaxRecordSize    = 64 << 20
+)
+
+// Storage adalah lapisan penyimpanan di balik Blockchain. Load dipanggil
+// sekali oleh NewBlockchain, Append untuk setiap block yang ditambahkan ke
+// ujung chain dan Replace ketika chain neighbor diadopsi.
+type Storage interface {
+	Load() ([]*Block, error)
+	Append(b *Block) error
+	Replace(chain []*Block) error
+	Close() error
+}
+
+// FileStorage menyimpan chain sebagai log append-only berisi block JSON
+// dengan prefix panjang dan checksum, ditambah file index yang menyimpan
+// offset setiap record di log.
+type FileStorage struct {
+	dir   string
+	log   *os.File
+	index *os.File
+	size  int64
+}
+
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(filepath.Join(dir, STORAGE_LOG_FILE), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	indexFile, err := os.OpenFile(filepath.Join(dir, STORAGE_INDEX_FILE), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	return &FileStorage{dir: dir, log: logFile, index: indexFile}, nil
+}
+
+func (fs *FileStorage) Dir() string {
+	return fs.dir
+}
+
+// Load membaca semua record yang utuh dari log block. Record terakhir yang
+// hanya tertulis sebagian (atau checksum-nya salah) dipotong, dan index
+// dibangun ulang bila tidak cocok dengan log.
+func (fs *FileStorage) Load() ([]*Block, error) {
+	if _, err := fs.log.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(fs.log)
+
+	var blocks []*Block
+	var offsets []int64
+	var offset int64
+	for {
+		payload, err := readStorageRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Storage: dropping broken record at offset %d: %v", offset, err)
+			break
+		}
+		b := new(Block)
+		if err := json.Unmarshal(payload, b); err != nil {
+			log.Printf("Storage: dropping undecodable block at offset %d: %v", offset, err)
+			break
+		}
+		blocks = append(blocks, b)
+		offsets = append(offsets, offset)
+		offset += int64(storageRecordHeaderSize + len(payload))
+	}
+
+	info, err := fs.log.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != offset {
+		log.Printf("Storage: truncating block log from %d to %d bytes", info.Size(), offset)
+		if err := fs.log.Truncate(offset); err != nil {
+			return nil, err
+		}
+		if err := fs.log.Sync(); err != nil {
+			return nil, err
+		}
+	}
+	fs.size = offset
+
+	if !fs.indexMatches(offsets) {
+		log.Printf("Storage: rebuilding block index with %d entries", len(offsets))
+		if err := fs.writeIndex(offsets); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("Storage: loaded %d blocks from %s", len(blocks), fs.dir)
+	return blocks, nil
+}
+
+func (fs *FileStorage) Append(b *Block) error {
+	record, err := encodeStorageRecord(b)
+	if err != nil {
+		return err
+	}
+	if _, err := fs.log.WriteAt(record, fs.size); err != nil {
+		return err
+	}
+	if err := fs.log.Sync(); err != nil {
+		return err
+	}
+
+	entry := make([]byte, storageIndexEntrySize)
+	binary.BigEndian.PutUint64(entry, uint64(fs.size))
+	if _, err := fs.index.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if _, err := fs.index.Write(entry); err != nil {
+		return err
+	}
+	fs.size += int64(len(record))
+	return fs.index.Sync()
+}
+
+// Replace menulis ulang seluruh log ke file sementara lalu me-rename-nya
+// menimpa log lama, sehingga crash meninggalkan chain lama atau chain baru
+// di disk, tidak pernah campuran keduanya.
+func (fs *FileStorage) Replace(chain []*Block) error {
+	tmpPath := filepath.Join(fs.dir, STORAGE_LOG_FILE+".tmp")
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	offsets := make([]int64, 0, len(chain))
+	var offset int64
+	for _, b := range chain {
+		record, err := encodeStorageRecord(b)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if _, err := tmp.Write(record); err != nil {
+			tmp.Close()
+			return err
+		}
+		offsets = append(offsets, offset)
+		offset += int64(len(record))
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(fs.dir, STORAGE_LOG_FILE)); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	fs.log.Close()
+	fs.log = tmp
+	fs.size = offset
+	return fs.writeIndex(offsets)
+}
+
+func (fs *FileStorage) Close() error {
+	errLog := fs.log.Close()
+	errIndex := fs.index.Close()
+	if errLog != nil {
+		return errLog
+	}
+	return errIndex
+}
+
+func (fs *FileStorage) indexMatches(offsets []int64) bool {
+	info, err := fs.index.Stat()
+	if err != nil || info.Size() != int64(len(offsets)*storageIndexEntrySize) {
+		return false
+	}
+	buf := make([]byte, info.Size())
+	if _, err := fs.index.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return false
+	}
+	for i, o := range offsets {
+		if int64(binary.BigEndian.Uint64(buf[i*storageIndexEntrySize:])) != o {
+			return false
+		}
+	}
+	return true
+}
+
+func (fs *FileStorage) writeIndex(offsets []int64) error {
+	buf := make([]byte, len(offsets)*storageIndexEntrySize)
+	for i, o := range offsets {
+		binary.BigEndian.PutUint64(buf[i*storageIndexEntrySize:], uint64(o))
+	}
+	if err := fs.index.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := fs.index.WriteAt(buf, 0); err != nil {
+		return err
+	}
+	return fs.index.Sync()
+}
+
+func encodeStorageRecord(v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, storageRecordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[storageRecordHeaderSize:], payload)
+	return record, nil
+}
+
+func readStorageRecord(r io.Reader) ([]byte, error) {
+	header := make([]byte, storageRecordHeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("short record header (%d bytes)", n)
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if length > storageMaxRecordSize {
+		return nil, fmt.Errorf("record length %d too large", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, errors.New("short record payload")
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, errors.New("checksum mismatch")
+	}
+	return payload, nil
+}
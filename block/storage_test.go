@@ -0,0 +1,219 @@
+package block
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testChainBlocks(n int) []*Block {
+	chain := []*Block{GenesisBlock()}
+	for len(chain) < n {
+		chain = append(chain, nextTestBlock(chain, "miner"))
+	}
+	return chain
+}
+
+func openTestStorage(t *testing.T, dir string) *FileStorage {
+	t.Helper()
+	fs, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fs.Close() })
+	return fs
+}
+
+func assertBlocks(t *testing.T, got []*Block, want []*Block) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("loaded %d blocks, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Hash() != want[i].Hash() {
+			t.Errorf("block %d hash = %x, want %x", i, got[i].Hash(), want[i].Hash())
+		}
+	}
+}
+
+func TestFileStorageAppendLoad(t *testing.T) {
+	dir := t.TempDir()
+	chain := testChainBlocks(4)
+
+	fs := openTestStorage(t, dir)
+	for _, b := range chain {
+		if err := fs.Append(b); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	fs.Close()
+
+	loaded, err := openTestStorage(t, dir).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	assertBlocks(t, loaded, chain)
+}
+
+func TestFileStorageRecovery(t *testing.T) {
+	chain := testChainBlocks(3)
+	tests := []struct {
+		name string
+		// damage merusak file log yang berisi chain lengkap
+		damage func(t *testing.T, path string, size int64)
+		want   int
+	}{
+		{
+			name:   "intact",
+			damage: func(t *testing.T, path string, size int64) {},
+			want:   3,
+		},
+		{
+			name: "truncated payload",
+			damage: func(t *testing.T, path string, size int64) {
+				if err := os.Truncate(path, size-5); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: 2,
+		},
+		{
+			name: "truncated header",
+			damage: func(t *testing.T, path string, size int64) {
+				appendBytes(t, path, []byte{0, 0, 0})
+			},
+			want: 3,
+		},
+		{
+			name: "checksum mismatch",
+			damage: func(t *testing.T, path string, size int64) {
+				f, err := os.OpenFile(path, os.O_RDWR, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				if _, err := f.WriteAt([]byte{'#'}, size-2); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: 2,
+		},
+		{
+			name: "oversized length",
+			damage: func(t *testing.T, path string, size int64) {
+				appendBytes(t, path, []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+			},
+			want: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fs := openTestStorage(t, dir)
+			if err := fs.Replace(chain); err != nil {
+				t.Fatal(err)
+			}
+			size := fs.size
+			fs.Close()
+
+			path := filepath.Join(dir, STORAGE_LOG_FILE)
+			tt.damage(t, path, size)
+
+			fs = openTestStorage(t, dir)
+			loaded, err := fs.Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			assertBlocks(t, loaded, chain[:tt.want])
+
+			// Log dipotong ke record terakhir yang utuh, jadi block baru
+			// bisa langsung ditambahkan di belakangnya
+			next := nextTestBlock(loaded, "miner")
+			if err := fs.Append(next); err != nil {
+				t.Fatal(err)
+			}
+			fs.Close()
+			reloaded, err := openTestStorage(t, dir).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertBlocks(t, reloaded, append(loaded, next))
+		})
+	}
+}
+
+func TestFileStorageRebuildsIndex(t *testing.T) {
+	dir := t.TempDir()
+	chain := testChainBlocks(3)
+	fs := openTestStorage(t, dir)
+	if err := fs.Replace(chain); err != nil {
+		t.Fatal(err)
+	}
+	fs.Close()
+
+	indexPath := filepath.Join(dir, STORAGE_INDEX_FILE)
+	if err := os.WriteFile(indexPath, []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openTestStorage(t, dir).Load(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(len(chain) * storageIndexEntrySize); info.Size() != want {
+		t.Errorf("index size = %d, want %d", info.Size(), want)
+	}
+}
+
+func appendBytes(t *testing.T, path string, b []byte) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// failingStorage menolak setiap penulisan, seperti disk yang penuh.
+type failingStorage struct{}
+
+var errTestStorage = errors.New("disk full")
+
+func (failingStorage) Load() ([]*Block, error)      { return nil, nil }
+func (failingStorage) Append(b *Block) error        { return errTestStorage }
+func (failingStorage) Replace(chain []*Block) error { return errTestStorage }
+func (failingStorage) Close() error                 { return nil }
+
+func TestStorageFailureKeepsChain(t *testing.T) {
+	bc, signer, sender := fundedTestChain(t)
+	tx := signedTransaction(t, signer, sender, "recipient", 1, 0, 0)
+	if err := bc.mempool.Add(tx); err != nil {
+		t.Fatal(err)
+	}
+	bc.storage = failingStorage{}
+	before := append([]*Block(nil), bc.chain...)
+
+	if err := bc.appendBlock(nextTestBlock(bc.chain, "miner", tx)); !errors.Is(err, errTestStorage) {
+		t.Errorf("appendBlock error = %v, want %v", err, errTestStorage)
+	}
+	assertBlocks(t, bc.chain, before)
+
+	competing := append([]*Block(nil), before[:1]...)
+	for i := 0; i < 2; i++ {
+		competing = append(competing, nextTestBlock(competing, "miner"))
+	}
+	if err := bc.replaceChain(competing); !errors.Is(err, errTestStorage) {
+		t.Errorf("replaceChain error = %v, want %v", err, errTestStorage)
+	}
+	assertBlocks(t, bc.chain, before)
+
+	if bc.mempool.Len() != 1 {
+		t.Errorf("pool has %d transactions, want the pending transaction kept", bc.mempool.Len())
+	}
+}
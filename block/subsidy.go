@@ -0,0 +1,74 @@
+package block
+
+// Jadwal emisi: block di tinggi h (genesis = 0) boleh mencetak
+// BlockSubsidy(h) ditambah fee transaksinya. Subsidy dibagi dua setiap
+// SUBSIDY_HALVING_INTERVAL block, sehingga total emisi tidak pernah melewati
+// MAX_SUPPLY (2 * interval * subsidy awal, batas deret geometrisnya).
+const (
+	INITIAL_BLOCK_SUBSIDY    = 1 * AMOUNT_UNIT
+	SUBSIDY_HALVING_INTERVAL = 1000
+	MAX_SUPPLY               = 2 * SUBSIDY_HALVING_INTERVAL * INITIAL_BLOCK_SUBSIDY
+)
+
+// BlockSubsidy adalah koin baru yang boleh dicetak coinbase di tinggi height.
+// Genesis tidak memiliki coinbase.
+func BlockSubsidy(height int) Amount {
+	if height <= 0 {
+		return 0
+	}
+	halvings := (height - 1) / SUBSIDY_HALVING_INTERVAL
+	if halvings >= 64 {
+		return 0
+	}
+	return INITIAL_BLOCK_SUBSIDY >> uint(halvings)
+}
+
+// NextHalvingHeight adalah tinggi block pertama dengan subsidy yang sudah
+// dibagi dua setelah height.
+func NextHalvingHeight(height int) int {
+	if height < 1 {
+		height = 1
+	}
+	return ((height-1)/SUBSIDY_HALVING_INTERVAL+1)*SUBSIDY_HALVING_INTERVAL + 1
+}
+
+// ChainSupply adalah jumlah koin yang beredar di chain: semua coinbase
+// dikurangi fee, karena fee hanya berpindah dari sender ke miner.
+func ChainSupply(chain []*Block) Amount {
+	var minted, fees Amount
+	for _, b := range chain {
+		for _, t := range b.transactions {
+			if t.senderBlockchainAddress == MINING_SENDER {
+				minted += t.value
+			} else {
+				fees += t.fee
+			}
+		}
+	}
+	if fees > minted {
+		return 0
+	}
+	return minted - fees
+}
+
+type SupplyResponse struct {
+	Height            int    `json:"height"`
+	CirculatingSupply Amount `json:"circulating_supply"`
+	MaxSupply         Amount `json:"max_supply"`
+	BlockSubsidy      Amount `json:"block_subsidy"`
+	NextHalvingHeight int    `json:"next_halving_height"`
+}
+
+// Supply melaporkan jumlah koin yang beredar dan subsidy untuk block
+// berikutnya.
+func (bc *Blockchain) Supply() *SupplyResponse {
+	chain := bc.Chain()
+	height := len(chain) - 1
+	return &SupplyResponse{
+		Height:            height,
+		CirculatingSupply: ChainSupply(chain),
+		MaxSupply:         MAX_SUPPLY,
+		BlockSubsidy:      BlockSubsidy(height + 1),
+		NextHalvingHeight: NextHalvingHeight(height + 1),
+	}
+}
@@ -0,0 +1,81 @@
+package block
+
+import "testing"
+
+func TestBlockSubsidy(t *testing.T) {
+	tests := []struct {
+		height int
+		want   Amount
+	}{
+		{-1, 0},
+		{0, 0},
+		{1, INITIAL_BLOCK_SUBSIDY},
+		{SUBSIDY_HALVING_INTERVAL, INITIAL_BLOCK_SUBSIDY},
+		{SUBSIDY_HALVING_INTERVAL + 1, INITIAL_BLOCK_SUBSIDY / 2},
+		{2*SUBSIDY_HALVING_INTERVAL + 1, INITIAL_BLOCK_SUBSIDY / 4},
+		{26*SUBSIDY_HALVING_INTERVAL + 1, 1},
+		{27*SUBSIDY_HALVING_INTERVAL + 1, 0},
+		{64*SUBSIDY_HALVING_INTERVAL + 1, 0},
+	}
+	for _, tt := range tests {
+		if got := BlockSubsidy(tt.height); got != tt.want {
+			t.Errorf("BlockSubsidy(%d) = %s, want %s", tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestBlockSubsidyTotal(t *testing.T) {
+	var total Amount
+	for height := 1; ; height++ {
+		subsidy := BlockSubsidy(height)
+		if subsidy == 0 {
+			break
+		}
+		total += subsidy
+	}
+	if total > MAX_SUPPLY {
+		t.Errorf("total subsidy %s exceeds MAX_SUPPLY %s", total, MAX_SUPPLY)
+	}
+	// Subsidy dibulatkan ke bawah, jadi total hanya sedikit di bawah batas
+	if MAX_SUPPLY-total > 64*SUBSIDY_HALVING_INTERVAL {
+		t.Errorf("total subsidy %s is far below MAX_SUPPLY %s", total, MAX_SUPPLY)
+	}
+}
+
+func TestNextHalvingHeight(t *testing.T) {
+	tests := []struct {
+		height int
+		want   int
+	}{
+		{0, SUBSIDY_HALVING_INTERVAL + 1},
+		{1, SUBSIDY_HALVING_INTERVAL + 1},
+		{SUBSIDY_HALVING_INTERVAL, SUBSIDY_HALVING_INTERVAL + 1},
+		{SUBSIDY_HALVING_INTERVAL + 1, 2*SUBSIDY_HALVING_INTERVAL + 1},
+	}
+	for _, tt := range tests {
+		if got := NextHalvingHeight(tt.height); got != tt.want {
+			t.Errorf("NextHalvingHeight(%d) = %d, want %d", tt.height, got, tt.want)
+		}
+		if BlockSubsidy(tt.want) == BlockSubsidy(tt.want-1) {
+			t.Errorf("subsidy does not halve at height %d", tt.want)
+		}
+	}
+}
+
+func TestChainSupply(t *testing.T) {
+	bc, signer, sender := fundedTestChain(t)
+	tx := signedTransaction(t, signer, sender, "recipient", 10, 500, 0)
+	b := nextTestBlock(bc.chain, "miner", tx)
+	b.transactions[0].value += tx.fee
+	bc.appendBlock(b)
+
+	// Fee hanya berpindah ke miner, jadi supply sama dengan total subsidy
+	want := BlockSubsidy(1) + BlockSubsidy(2)
+	if got := ChainSupply(bc.chain); got != want {
+		t.Errorf("ChainSupply() = %s, want %s", got, want)
+	}
+	supply := bc.Supply()
+	if supply.Height != 2 || supply.CirculatingSupply != want || supply.BlockSubsidy != BlockSubsidy(3) {
+		t.Errorf("Supply() = %+v", supply)
+	}
+}
@@ -0,0 +1,295 @@
+package block
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// SYNC_MAX_BLOCKS membatasi jumlah block yang diunduh dalam satu putaran
+// sync; chain yang lebih panjang diambil bertahap di putaran berikutnya.
+// SYNC_MAX_RESPONSE_BYTES membatasi ukuran satu respons peer.
+const (
+	SYNC_HEADERS_BATCH_SIZE = 200
+	SYNC_HTTP_TIMEOUT_SEC   = 10
+	SYNC_MAX_BLOCKS         = 2000
+	SYNC_MAX_RESPONSE_BYTES = 16 << 20
+)
+
+var syncClient = &http.Client{Timeout: SYNC_HTTP_TIMEOUT_SEC * time.Second}
+
+type ChainTipResponse struct {
+	Height int
+	Hash   [32]byte
+	Work   *big.Int
+}
+
+func (tr *ChainTipResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Height int    `json:"height"`
+		Hash   string `json:"hash"`
+		Work   string `json:"work"`
+	}{
+		Height: tr.Height,
+		Hash:   hex.EncodeToString(tr.Hash[:]),
+		Work:   tr.Work.String(),
+	})
+}
+
+func (tr *ChainTipResponse) UnmarshalJSON(data []byte) error {
+	var hash, work string
+	v := &struct {
+		Height *int    `json:"height"`
+		Hash   *string `json:"hash"`
+		Work   *string `json:"work"`
+	}{
+		Height: &tr.Height,
+		Hash:   &hash,
+		Work:   &work,
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	h, err := hex.DecodeString(hash)
+	if err != nil || len(h) != 32 {
+		return errors.New("invalid tip hash")
+	}
+	copy(tr.Hash[:], h)
+	w, ok := new(big.Int).SetString(work, 10)
+	if !ok {
+		return errors.New("invalid tip work")
+	}
+	tr.Work = w
+	return nil
+}
+
+// HeadersResponse berisi header block, yaitu Block tanpa transaksi. Hash
+// header sama dengan hash block lengkapnya.
+type HeadersResponse struct {
+	Headers []*Block `json:"headers"`
+}
+
+// Header mengembalikan salinan block tanpa daftar transaksi.
+func (b *Block) Header() *Block {
+	return &Block{
+		timestamp:    b.timestamp,
+		nonce:        b.nonce,
+		previousHash: b.previousHash,
+		merkleRoot:   b.merkleRoot,
+		difficulty:   b.difficulty,
+	}
+}
+
+func (bc *Blockchain) Tip() *ChainTipResponse {
+	chain := bc.Chain()
+	return &ChainTipResponse{
+		Height: len(chain) - 1,
+		Hash:   chain[len(chain)-1].Hash(),
+		Work:   ChainWork(chain),
+	}
+}
+
+// Headers mengembalikan paling banyak count header mulai dari tinggi start.
+func (bc *Blockchain) Headers(start int, count int) []*Block {
+	chain := bc.Chain()
+	if count > SYNC_HEADERS_BATCH_SIZE {
+		count = SYNC_HEADERS_BATCH_SIZE
+	}
+	headers := make([]*Block, 0, count)
+	for i := start; i >= 0 && i < len(chain) && len(headers) < count; i += 1 {
+		headers = append(headers, chain[i].Header())
+	}
+	return headers
+}
+
+func (bc *Blockchain) BlockByHash(hash [32]byte) (*Block, bool) {
+	for _, b := range bc.Chain() {
+		if b.Hash() == hash {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func fetchJSON(endpoint string, v interface{}) error {
+	resp, err := syncClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, SYNC_MAX_RESPONSE_BYTES)).Decode(v)
+}
+
+func fetchTip(peer string) (*ChainTipResponse, error) {
+	var tip ChainTipResponse
+	err := fetchJSON(fmt.Sprintf("http://%s/chain/tip", peer), &tip)
+	return &tip, err
+}
+
+// fetchHeaders menolak respons yang berisi lebih dari count header atau
+// header kosong, supaya pemanggil bisa mengindeks hasilnya dengan aman.
+func fetchHeaders(peer string, start int, count int) ([]*Block, error) {
+	var hr HeadersResponse
+	if err := fetchJSON(fmt.Sprintf("http://%s/chain/headers?start=%d&count=%d", peer, start, count), &hr); err != nil {
+		return nil, err
+	}
+	if len(hr.Headers) > count {
+		return nil, fmt.Errorf("got %d headers, requested %d", len(hr.Headers), count)
+	}
+	for _, h := range hr.Headers {
+		if h == nil {
+			return nil, errors.New("null header")
+		}
+	}
+	return hr.Headers, nil
+}
+
+func fetchBlock(peer string, hash [32]byte) (*Block, error) {
+	var b Block
+	err := fetchJSON(fmt.Sprintf("http://%s/chain/block?hash=%x", peer, hash), &b)
+	return &b, err
+}
+
+// findCommonAncestor mencari tinggi block terakhir yang sama antara chain
+// lokal dan chain peer dengan berjalan mundur per batch header. Hasil -1
+// berarti kedua chain bahkan tidak berbagi genesis block.
+func findCommonAncestor(peer string, local []*Block, peerHeight int) (int, error) {
+	height := len(local) - 1
+	if peerHeight < height {
+		height = peerHeight
+	}
+	for height >= 0 {
+		start := height - SYNC_HEADERS_BATCH_SIZE + 1
+		if start < 0 {
+			start = 0
+		}
+		headers, err := fetchHeaders(peer, start, height-start+1)
+		if err != nil {
+			return -1, err
+		}
+		for i := len(headers) - 1; i >= 0; i -= 1 {
+			if headers[i].Hash() == local[start+i].Hash() {
+				return start + i, nil
+			}
+		}
+		height = start - 1
+	}
+	return -1, nil
+}
+
+// SyncWithPeer mengunduh hanya bagian chain peer yang belum dimiliki: header
+// setelah common ancestor divalidasi lebih dulu, lalu block lengkap diunduh
+// satu per satu berdasarkan hash dan divalidasi sambil jalan. Peer dengan
+// genesis berbeda ditolak, dan paling banyak SYNC_MAX_BLOCKS block diunduh
+// per putaran. Chain lokal diganti hanya bila chain peer lolos ValidChain
+// dan lebih berat (lihat heavierChain).
+func (bc *Blockchain) SyncWithPeer(peer string) bool {
+	local := bc.Chain()
+	localWork := ChainWork(local)
+	localTip := local[len(local)-1].Hash()
+
+	tip, err := fetchTip(peer)
+	if err != nil {
+		log.Printf("Sync: failed to get tip from %s: %v", peer, err)
+		return false
+	}
+	log.Printf("Sync: %s tip height %d, work %s (local height %d, work %s)",
+		peer, tip.Height, tip.Work, len(local)-1, localWork)
+	if tip.Height < 0 {
+		log.Printf("Sync: invalid tip height %d from %s", tip.Height, peer)
+		return false
+	}
+	if tip.Hash == localTip || tip.Work.Cmp(localWork) < 0 {
+		return false
+	}
+
+	ancestor, err := findCommonAncestor(peer, local, tip.Height)
+	if err != nil {
+		log.Printf("Sync: failed to find common ancestor with %s: %v", peer, err)
+		return false
+	}
+	if ancestor < 0 {
+		log.Printf("Sync: %s has a different genesis block", peer)
+		return false
+	}
+	log.Printf("Sync: common ancestor with %s at height %d", peer, ancestor)
+
+	// Tinggi tip berasal dari peer, jadi alokasi dan unduhan dibatasi
+	target := tip.Height
+	if target > ancestor+SYNC_MAX_BLOCKS {
+		target = ancestor + SYNC_MAX_BLOCKS
+	}
+
+	// Header diunduh dan divalidasi per batch sebelum transaksi diunduh
+	candidate := make([]*Block, ancestor+1, target+1)
+	copy(candidate, local[:ancestor+1])
+	for len(candidate) <= target {
+		count := target - len(candidate) + 1
+		if count > SYNC_HEADERS_BATCH_SIZE {
+			count = SYNC_HEADERS_BATCH_SIZE
+		}
+		headers, err := fetchHeaders(peer, len(candidate), count)
+		if err != nil || len(headers) == 0 {
+			log.Printf("Sync: failed to get headers from %s: %v", peer, err)
+			return false
+		}
+		for _, h := range headers {
+			if !bc.validHeader(candidate, h) {
+				log.Printf("Sync: invalid header from %s at height %d", peer, len(candidate))
+				return false
+			}
+			candidate = append(candidate, h)
+		}
+	}
+
+	if !heavierChain(candidate, ChainWork(candidate), local, localWork) {
+		log.Printf("Sync: chain from %s is not heavier, keeping local chain", peer)
+		return false
+	}
+
+	for i := ancestor + 1; i < len(candidate); i += 1 {
+		hash := candidate[i].Hash()
+		b, err := fetchBlock(peer, hash)
+		if err != nil {
+			log.Printf("Sync: failed to get block %x from %s: %v", hash, peer, err)
+			return false
+		}
+		if b.Hash() != hash || !bc.validBody(i, b) {
+			log.Printf("Sync: invalid block %x from %s at height %d", hash, peer, i)
+			return false
+		}
+		candidate[i] = b
+	}
+
+	// Kerja chain hanya dibandingkan setelah seluruh chain lolos ValidChain,
+	// termasuk genesis dan batas supply
+	if !bc.ValidChain(candidate) {
+		log.Printf("Sync: chain from %s rejected", peer)
+		return false
+	}
+
+	// Miner bisa menambah block selama unduhan berjalan; bandingkan ulang
+	// dengan chain lokal terbaru sebelum mengganti
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	if current := bc.chain; current[len(current)-1].Hash() != localTip &&
+		!heavierChain(candidate, ChainWork(candidate), current, ChainWork(current)) {
+		log.Printf("Sync: local chain grew while syncing with %s, keeping local chain", peer)
+		return false
+	}
+	if err := bc.replaceChain(candidate); err != nil {
+		log.Printf("ERROR: Failed to adopt chain from %s: %v", peer, err)
+		return false
+	}
+	log.Printf("Sync: adopted chain from %s, %d new blocks", peer, len(candidate)-ancestor-1)
+	return true
+}
@@ -0,0 +1,354 @@
+package block
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// testPeer melayani endpoint sync milik blockchain_server di atas chain
+// peer. bodies menggantikan block lengkap yang dikirim untuk hash tertentu.
+type testPeer struct {
+	bc           *Blockchain
+	bodies       map[[32]byte]*Block
+	blockFetches atomic.Int32
+}
+
+func (p *testPeer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	switch req.URL.Path {
+	case "/chain/tip":
+		m, _ := p.bc.Tip().MarshalJSON()
+		w.Write(m)
+	case "/chain/headers":
+		start, _ := strconv.Atoi(query.Get("start"))
+		count, _ := strconv.Atoi(query.Get("count"))
+		m, _ := json.Marshal(&HeadersResponse{Headers: p.bc.Headers(start, count)})
+		w.Write(m)
+	case "/chain/block":
+		p.blockFetches.Add(1)
+		var hash [32]byte
+		h, _ := hex.DecodeString(query.Get("hash"))
+		copy(hash[:], h)
+		b, ok := p.bodies[hash]
+		if !ok {
+			b, ok = p.bc.BlockByHash(hash)
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		m, _ := b.MarshalJSON()
+		w.Write(m)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func startTestPeer(t *testing.T, chain []*Block) (*testPeer, string) {
+	t.Helper()
+	p := &testPeer{bc: NewBlockchain("peer", 0, nil, 0), bodies: map[[32]byte]*Block{}}
+	p.bc.chain = chain
+	server := httptest.NewServer(p)
+	t.Cleanup(server.Close)
+	return p, strings.TrimPrefix(server.URL, "http://")
+}
+
+func extendTestChain(t *testing.T, chain []*Block, miner string, n int) []*Block {
+	t.Helper()
+	chain = append([]*Block(nil), chain...)
+	for i := 0; i < n; i += 1 {
+		chain = append(chain, mineTestBlock(t, chain, miner))
+	}
+	return chain
+}
+
+func TestSyncWithPeer(t *testing.T) {
+	bc, _, _ := fundedTestChain(t)
+	base := append([]*Block(nil), bc.chain...)
+
+	tests := []struct {
+		name string
+		// local adalah chain lokal sebelum sync
+		local []*Block
+		peer  func(t *testing.T, p *testPeer)
+		want  bool
+		// fetches adalah jumlah block lengkap yang diunduh dari peer
+		fetches int32
+	}{
+		{
+			name:    "same tip",
+			local:   base,
+			peer:    func(t *testing.T, p *testPeer) {},
+			want:    false,
+			fetches: 0,
+		},
+		{
+			name:  "lighter peer",
+			local: extendTestChain(t, base, "local", 2),
+			peer: func(t *testing.T, p *testPeer) {
+				p.bc.chain = extendTestChain(t, base, "peer", 1)
+			},
+			want:    false,
+			fetches: 0,
+		},
+		{
+			name:  "extension",
+			local: base,
+			peer: func(t *testing.T, p *testPeer) {
+				p.bc.chain = extendTestChain(t, base, "peer", 3)
+			},
+			want:    true,
+			fetches: 3,
+		},
+		{
+			name:  "reorg",
+			local: extendTestChain(t, base, "local", 1),
+			peer: func(t *testing.T, p *testPeer) {
+				p.bc.chain = extendTestChain(t, base, "peer", 2)
+			},
+			want:    true,
+			fetches: 2,
+		},
+		{
+			name:  "invalid header difficulty",
+			local: base,
+			peer: func(t *testing.T, p *testPeer) {
+				chain := extendTestChain(t, base, "peer", 1)
+				next := nextTestBlock(chain, "peer")
+				mined, err := ProofOfWork(context.Background(), next.previousHash, next.difficulty+1, next.transactions)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.bc.chain = append(chain, mined)
+			},
+			want:    false,
+			fetches: 0,
+		},
+		{
+			name:  "body does not match merkle root",
+			local: base,
+			peer: func(t *testing.T, p *testPeer) {
+				p.bc.chain = extendTestChain(t, base, "peer", 2)
+				tip := p.bc.chain[len(p.bc.chain)-1]
+				forged := tip.Header()
+				forged.transactions = nextTestBlock(p.bc.chain[:len(p.bc.chain)-1], "thief").transactions
+				p.bodies[tip.Hash()] = forged
+			},
+			want:    false,
+			fetches: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewBlockchain("local", 0, nil, 0)
+			local.chain = append([]*Block(nil), tt.local...)
+			p, addr := startTestPeer(t, append([]*Block(nil), base...))
+			tt.peer(t, p)
+
+			if got := local.SyncWithPeer(addr); got != tt.want {
+				t.Fatalf("SyncWithPeer() = %v, want %v", got, tt.want)
+			}
+			if got := p.blockFetches.Load(); got != tt.fetches {
+				t.Errorf("fetched %d blocks, want %d", got, tt.fetches)
+			}
+
+			want := tt.local
+			if tt.want {
+				want = p.bc.chain
+			}
+			assertBlocks(t, local.Chain(), want)
+		})
+	}
+}
+
+func TestSyncWithPeerUnreachable(t *testing.T) {
+	bc, _, _ := fundedTestChain(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	addr := strings.TrimPrefix(server.URL, "http://")
+	server.Close()
+
+	before := bc.Chain()
+	if bc.SyncWithPeer(addr) {
+		t.Error("SyncWithPeer() = true for an unreachable peer")
+	}
+	assertBlocks(t, bc.Chain(), before)
+}
+
+func TestChainTipJSON(t *testing.T) {
+	bc, _, _ := fundedTestChain(t)
+	tip := bc.Tip()
+	data, err := tip.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ChainTipResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Height != tip.Height || decoded.Hash != tip.Hash || decoded.Work.Cmp(tip.Work) != 0 {
+		t.Errorf("decoded tip = %+v, want %+v", decoded, tip)
+	}
+
+	for _, bad := range []string{
+		`{"height":1,"hash":"abcd","work":"1"}`,
+		`{"height":1,"hash":"` + strings.Repeat("ab", 32) + `","work":"x"}`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &decoded); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want error", bad)
+		}
+	}
+}
+
+// rawPeer mengirim respons tetap untuk tip dan header, seperti peer yang
+// rusak atau jahat.
+func rawPeer(t *testing.T, tip string, headers string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/chain/tip":
+			w.Write([]byte(tip))
+		case "/chain/headers":
+			w.Write([]byte(headers))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestSyncWithPeerMalformed(t *testing.T) {
+	bc, _, _ := fundedTestChain(t)
+	base := append([]*Block(nil), bc.chain...)
+
+	long := append([]*Block(nil), base...)
+	for len(long) < 50 {
+		long = append(long, nextTestBlock(long, "peer"))
+	}
+	headers := func(chain []*Block) string {
+		h := make([]*Block, len(chain))
+		for i, b := range chain {
+			h[i] = b.Header()
+		}
+		m, _ := json.Marshal(&HeadersResponse{Headers: h})
+		return string(m)
+	}
+	tip := func(height int) string {
+		return `{"height":` + strconv.Itoa(height) + `,"hash":"` + strings.Repeat("ab", 32) + `","work":"1000000000000"}`
+	}
+
+	tests := []struct {
+		name    string
+		tip     string
+		headers string
+	}{
+		{"negative height", tip(-5), headers(base)},
+		{"huge height", tip(1 << 40), headers(base)},
+		{"too many headers", tip(len(base)), headers(long)},
+		{"null header", tip(len(base)), `{"headers":[null]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewBlockchain("local", 0, nil, 0)
+			local.chain = append([]*Block(nil), base...)
+			if local.SyncWithPeer(rawPeer(t, tt.tip, tt.headers)) {
+				t.Error("SyncWithPeer() = true, want false")
+			}
+			assertBlocks(t, local.Chain(), base)
+		})
+	}
+}
+
+func TestSyncRejectsDifferentGenesis(t *testing.T) {
+	genesis := GenesisBlock()
+	genesis.timestamp += 1
+	chain := extendTestChain(t, []*Block{genesis}, "peer", 3)
+
+	local := NewBlockchain("local", 0, nil, 0)
+	before := local.Chain()
+	p, addr := startTestPeer(t, chain)
+	if local.SyncWithPeer(addr) {
+		t.Error("SyncWithPeer() = true for a peer with another genesis")
+	}
+	if got := p.blockFetches.Load(); got != 0 {
+		t.Errorf("fetched %d blocks, want 0", got)
+	}
+	assertBlocks(t, local.Chain(), before)
+}
+
+// recordingStorage mencatat penulisan ke storage dan menolak Append setelah
+// failAfter block bila failAfter tidak negatif.
+type recordingStorage struct {
+	appended  []*Block
+	replaced  int
+	failAfter int
+}
+
+func (s *recordingStorage) Load() ([]*Block, error) { return nil, nil }
+func (s *recordingStorage) Close() error            { return nil }
+
+func (s *recordingStorage) Append(b *Block) error {
+	if s.failAfter >= 0 && len(s.appended) >= s.failAfter {
+		return errTestStorage
+	}
+	s.appended = append(s.appended, b)
+	return nil
+}
+
+func (s *recordingStorage) Replace(chain []*Block) error {
+	s.replaced += 1
+	return nil
+}
+
+func TestReplaceChainStorage(t *testing.T) {
+	bc, _, _ := fundedTestChain(t)
+	base := append([]*Block(nil), bc.chain...)
+	extended := append([]*Block(nil), base...)
+	for i := 0; i < 3; i++ {
+		extended = append(extended, nextTestBlock(extended, "peer"))
+	}
+	reorg := append([]*Block(nil), base[:1]...)
+	for i := 0; i < 3; i++ {
+		reorg = append(reorg, nextTestBlock(reorg, "other"))
+	}
+
+	tests := []struct {
+		name      string
+		chain     []*Block
+		failAfter int
+		// want adalah chain di memori setelah replaceChain
+		want     []*Block
+		appended int
+		replaced int
+		wantErr  bool
+	}{
+		{"extension appends", extended, -1, extended, 3, 0, false},
+		{"reorg replaces", reorg, -1, reorg, 0, 1, false},
+		{"first append fails", extended, 0, base, 0, 0, true},
+		{"later append fails", extended, 2, extended[:len(base)+2], 2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &recordingStorage{failAfter: tt.failAfter}
+			bc.chain = append([]*Block(nil), base...)
+			bc.storage = storage
+
+			err := bc.replaceChain(tt.chain)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("replaceChain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assertBlocks(t, bc.chain, tt.want)
+			assertBlocks(t, storage.appended, tt.chain[len(base):len(base)+tt.appended])
+			if storage.replaced != tt.replaced {
+				t.Errorf("Replace called %d times, want %d", storage.replaced, tt.replaced)
+			}
+		})
+	}
+}
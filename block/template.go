@@ -0,0 +1,108 @@
+package block
+
+import "math/bits"
+
+// Batas block dihitung dari encoding biner transaksi (Transaction.Bytes),
+// termasuk coinbase.
+const (
+	MAX_BLOCK_SIZE         = 1 << 20
+	MAX_BLOCK_TRANSACTIONS = 2000
+)
+
+// Size adalah panjang encoding biner transaksi dalam byte.
+func (t *Transaction) Size() int {
+	return len(t.Bytes())
+}
+
+// TransactionsSize adalah total Size semua transaksi.
+func TransactionsSize(transactions []*Transaction) int {
+	size := 0
+	for _, t := range transactions {
+		size += t.Size()
+	}
+	return size
+}
+
+// higherFeeRate melaporkan apakah fee/size a lebih besar dari fee/size b.
+// Perbandingan dilakukan dengan perkalian silang 128 bit supaya tidak ada
+// pembulatan maupun overflow.
+func higherFeeRate(a *Transaction, aSize int, b *Transaction, bSize int) bool {
+	ah, al := bits.Mul64(uint64(a.fee), uint64(bSize))
+	bh, bl := bits.Mul64(uint64(b.fee), uint64(aSize))
+	if ah != bh {
+		return ah > bh
+	}
+	return al > bl
+}
+
+// senderQueue adalah transaksi pool milik satu sender, urut nonce.
+type senderQueue struct {
+	transactions []*Transaction
+	sizes        []int
+}
+
+// BlockTemplate menyusun transaksi untuk block berikutnya: coinbase berisi
+// BlockSubsidy ditambah fee di posisi pertama, lalu transaksi pool dengan
+// fee per byte tertinggi lebih dulu sampai MAX_BLOCK_SIZE atau
+// MAX_BLOCK_TRANSACTIONS tercapai. Transaksi satu sender diambil dari
+// antrean Mempool.Pending berurutan nonce, jadi rangkaian pembayaran yang
+// saling bergantung ditambang berurutan dan transaksi dengan fee tinggi
+// tidak bisa melompati nonce sebelumnya. Transaksi yang tidak terpilih
+// tetap di pool. Pemanggil memegang bc.mux.
+func (bc *Blockchain) BlockTemplate() []*Transaction {
+	subsidy := BlockSubsidy(len(bc.chain))
+	coinbase := NewTransaction(MINING_SENDER, bc.blockchainAddress, subsidy, 0, uint64(len(bc.chain)))
+	size := coinbase.Size()
+
+	queues := make(map[string]*senderQueue)
+	var senders []string
+	for _, pending := range bc.mempool.Pending() {
+		q := &senderQueue{transactions: pending}
+		for _, t := range pending {
+			q.sizes = append(q.sizes, t.Size())
+		}
+		sender := pending[0].senderBlockchainAddress
+		queues[sender] = q
+		senders = append(senders, sender)
+	}
+
+	transactions := []*Transaction{coinbase}
+	var fees Amount
+	for len(transactions) < MAX_BLOCK_TRANSACTIONS {
+		// Pilih kepala antrean sender dengan fee per byte tertinggi yang
+		// masih muat. Sender yang diproses dulu menang bila fee rate sama.
+		best := ""
+		for _, sender := range senders {
+			q := queues[sender]
+			if len(q.transactions) == 0 || size+q.sizes[0] > MAX_BLOCK_SIZE {
+				continue
+			}
+			if best == "" || higherFeeRate(q.transactions[0], q.sizes[0],
+				queues[best].transactions[0], queues[best].sizes[0]) {
+				best = sender
+			}
+		}
+		if best == "" {
+			break
+		}
+
+		q := queues[best]
+		t := q.transactions[0]
+		total, err := fees.Add(t.fee)
+		if err != nil {
+			// Fee sender ini tidak bisa dijumlahkan lagi, sisa antreannya
+			// menunggu block berikutnya.
+			q.transactions = nil
+			continue
+		}
+		fees = total
+		size += q.sizes[0]
+		transactions = append(transactions, t)
+		q.transactions, q.sizes = q.transactions[1:], q.sizes[1:]
+	}
+
+	if reward, err := fees.Add(subsidy); err == nil {
+		coinbase.value = reward
+	}
+	return transactions
+}
@@ -0,0 +1,67 @@
+package block
+
+import "testing"
+
+func TestHigherFeeRate(t *testing.T) {
+	fee := func(f Amount) *Transaction { return &Transaction{fee: f} }
+	tests := []struct {
+		name  string
+		a     *Transaction
+		aSize int
+		b     *Transaction
+		bSize int
+		want  bool
+	}{
+		{"higher fee same size", fee(20), 100, fee(10), 100, true},
+		{"same rate", fee(10), 100, fee(20), 200, false},
+		{"lower rate despite higher fee", fee(20), 250, fee(10), 100, false},
+		{"higher rate despite lower fee", fee(10), 100, fee(20), 250, true},
+		{"no overflow at max fee", fee(MAX_AMOUNT), 3, fee(MAX_AMOUNT - 1), 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := higherFeeRate(tt.a, tt.aSize, tt.b, tt.bSize); got != tt.want {
+				t.Errorf("higherFeeRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockTemplate(t *testing.T) {
+	bc, alice, aliceAddress := fundedTestChain(t)
+	bob, bobAddress := newTestSigner(t)
+	bc.appendBlock(nextTestBlock(bc.chain, bobAddress))
+
+	// Nonce 1 milik alice membayar fee tertinggi, tetapi baru bisa masuk
+	// setelah nonce 0 yang fee-nya paling rendah
+	alice0 := signedTransaction(t, alice, aliceAddress, "recipient", 1, 100, 0)
+	alice1 := signedTransaction(t, alice, aliceAddress, "recipient", 1, 10000, 1)
+	bob0 := signedTransaction(t, bob, bobAddress, "recipient", 1, 5000, 0)
+	for _, tx := range []*Transaction{alice0, alice1, bob0} {
+		if err := bc.mempool.Add(tx); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	transactions := bc.BlockTemplate()
+	want := []*Transaction{bob0, alice0, alice1}
+	if len(transactions) != len(want)+1 {
+		t.Fatalf("template has %d transactions, want %d", len(transactions), len(want)+1)
+	}
+	for i, tx := range want {
+		if transactions[i+1].Hash() != tx.Hash() {
+			t.Errorf("transaction %d has fee %s, want fee %s", i+1, transactions[i+1].fee, tx.fee)
+		}
+	}
+
+	coinbase := transactions[0]
+	if coinbase.senderBlockchainAddress != MINING_SENDER || coinbase.nonce != uint64(len(bc.chain)) {
+		t.Errorf("first transaction is not the coinbase for height %d", len(bc.chain))
+	}
+	if want := BlockSubsidy(len(bc.chain)) + 100 + 10000 + 5000; coinbase.value != want {
+		t.Errorf("coinbase value = %s, want %s", coinbase.value, want)
+	}
+	if bc.mempool.Len() != 3 {
+		t.Errorf("pool has %d transactions, BlockTemplate must not remove them", bc.mempool.Len())
+	}
+}
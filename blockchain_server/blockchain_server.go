@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"learn-blockchain/block"
@@ -8,35 +10,110 @@ import (
 	"learn-blockchain/wallet"
 	"log"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 type BlockchainServer struct {
-	port uint16
+	port          uint16
+	dataDir       string
+	minerKeyPath  string
+	rewardAddress string
+
+	coinbaseMaturity int
+	// adminToken mengaktifkan endpoint /admin/*; kosong berarti nonaktif.
+	adminToken string
 }
 
 var cache map[string]*block.Blockchain = make(map[string]*block.Blockchain)
 
-func NewBlockchainServer(port uint16) *BlockchainServer {
-	return &BlockchainServer{port}
+func NewBlockchainServer(port uint16, dataDir string, minerKeyPath string, rewardAddress string, coinbaseMaturity int, adminToken string) *BlockchainServer {
+	return &BlockchainServer{
+		port:             port,
+		dataDir:          dataDir,
+		minerKeyPath:     minerKeyPath,
+		rewardAddress:    rewardAddress,
+		coinbaseMaturity: coinbaseMaturity,
+		adminToken:       adminToken,
+	}
 }
 
 func (bcs *BlockchainServer) Port() uint16 {
 	return bcs.port
 }
 
+func (bcs *BlockchainServer) DataDir() string {
+	return bcs.dataDir
+}
+
+func (bcs *BlockchainServer) nodeDir() string {
+	return filepath.Join(bcs.DataDir(), strconv.Itoa(int(bcs.Port())))
+}
+
+// MinerKeyPath adalah lokasi key file miner. Default-nya miner.key di dalam
+// direktori data node.
+func (bcs *BlockchainServer) MinerKeyPath() string {
+	if bcs.minerKeyPath != "" {
+		return bcs.minerKeyPath
+	}
+	return filepath.Join(bcs.nodeDir(), "miner.key")
+}
+
+// coinbaseAddress menentukan ke mana reward mining dikirim: reward address
+// eksternal bila diset (node tidak memegang key-nya), atau address dari key
+// miner yang dimuat/dibuat sekali di MinerKeyPath.
+func (bcs *BlockchainServer) coinbaseAddress() string {
+	if bcs.rewardAddress != "" {
+		log.Printf("Mining rewards go to external address %s", bcs.rewardAddress)
+		return bcs.rewardAddress
+	}
+
+	path := bcs.MinerKeyPath()
+	if bcs.DataDir() == "" && bcs.minerKeyPath == "" {
+		// Tanpa direktori data, identitas miner juga hanya hidup di memori
+		minersWallet := wallet.NewWallet()
+		log.Printf("Using ephemeral miner address %s", minersWallet.BlockchainAddress())
+		return minersWallet.BlockchainAddress()
+	}
+	minersWallet, err := wallet.LoadOrCreateKeyFile(path)
+	if err != nil {
+		log.Fatalf("Failed to load miner key from %s: %v", path, err)
+	}
+	log.Printf("Loaded miner key from %s", path)
+	log.Printf("public_key %v", minersWallet.PublicKeyStr())
+	log.Printf("blockchain_address %v", minersWallet.BlockchainAddress())
+	return minersWallet.BlockchainAddress()
+}
+
 func (bcs *BlockchainServer) GetBlockchain() *block.Blockchain {
 	bc, ok := cache["blockchain"]
 	if !ok {
-		minersWallet := wallet.NewWallet()
-		bc = block.NewBlockchain(minersWallet.BlockchainAddress(), bcs.Port())
+		var storage block.Storage
+		if bcs.DataDir() != "" {
+			dir := bcs.nodeDir()
+			fs, err := block.NewFileStorage(dir)
+			if err != nil {
+				log.Fatalf("Failed to open chain storage at %s: %v", dir, err)
+			}
+			storage = fs
+		}
+
+		bc = block.NewBlockchain(bcs.coinbaseAddress(), bcs.Port(), storage, bcs.coinbaseMaturity)
 		if bc == nil {
 			log.Fatal("Failed to create new Blockchain!")
 		}
+		if bcs.DataDir() != "" {
+			dir := bcs.nodeDir()
+			journal, err := block.NewMempoolJournal(dir)
+			if err == nil {
+				err = bc.RestoreMempool(journal)
+			}
+			if err != nil {
+				log.Fatalf("Failed to open mempool journal at %s: %v", dir, err)
+			}
+		}
 		cache["blockchain"] = bc
-		log.Printf("private_key %v", minersWallet.PrivateKeyStr())
-		log.Printf("public_key %v", minersWallet.PublicKeyStr())
-		log.Printf("blockchain_address %v", minersWallet.BlockchainAddress())
 	}
 	log.Printf("Returning blockchain: %v", bc) // Tambahkan ini untuk debug
 	return bc
@@ -54,6 +131,69 @@ func (bcs *BlockchainServer) GetChain(w http.ResponseWriter, req *http.Request)
 	}
 }
 
+func (bcs *BlockchainServer) ChainTip(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		w.Header().Add("Content-Type", "application/json")
+		m, _ := bcs.GetBlockchain().Tip().MarshalJSON()
+		io.WriteString(w, string(m[:]))
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (bcs *BlockchainServer) ChainHeaders(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		start, errStart := strconv.Atoi(req.URL.Query().Get("start"))
+		count, errCount := strconv.Atoi(req.URL.Query().Get("count"))
+		if errStart != nil || errCount != nil || start < 0 || count <= 0 {
+			log.Println("ERROR: Invalid header range")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		m, _ := json.Marshal(&block.HeadersResponse{
+			Headers: bcs.GetBlockchain().Headers(start, count),
+		})
+		io.WriteString(w, string(m[:]))
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (bcs *BlockchainServer) ChainBlock(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		h, err := hex.DecodeString(req.URL.Query().Get("hash"))
+		if err != nil || len(h) != 32 {
+			log.Println("ERROR: Invalid block hash")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		var hash [32]byte
+		copy(hash[:], h)
+
+		w.Header().Add("Content-Type", "application/json")
+		b, ok := bcs.GetBlockchain().BlockByHash(hash)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		m, _ := b.MarshalJSON()
+		io.WriteString(w, string(m[:]))
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
 func (bcs *BlockchainServer) Transactions(w http.ResponseWriter, req *http.Request) {
 	switch req.Method {
 	case http.MethodGet:
@@ -83,17 +223,24 @@ func (bcs *BlockchainServer) Transactions(w http.ResponseWriter, req *http.Reque
 			io.WriteString(w, string(utils.JsonStatus("fail")))
 			return
 		}
-		publicKey := utils.PublicKeyFromString(*t.SenderPublicKey)
-		signature := utils.SignatureFromString(*t.Signature)
+		publicKey, signature, err := t.KeyAndSignature()
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.Header().Add("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
 		bc := bcs.GetBlockchain()
-		isCreated := bc.CreateTransaction(*t.SenderBlockchainAddress,
-			*t.RecipientBlockchainAddress, *t.Value, publicKey, signature)
+		err = bc.CreateTransaction(*t.SenderBlockchainAddress,
+			*t.RecipientBlockchainAddress, *t.Value, t.FeeAmount(), *t.Nonce, publicKey, signature)
 
 		w.Header().Add("Content-Type", "application/json")
 		var m []byte
-		if !isCreated {
+		if err != nil {
+			log.Printf("ERROR: %v", err)
 			w.WriteHeader(http.StatusBadRequest)
-			m = utils.JsonStatus("fail")
+			m = utils.JsonError(err)
 		} else {
 			w.WriteHeader(http.StatusCreated)
 			m = utils.JsonStatus("success")
@@ -113,25 +260,28 @@ func (bcs *BlockchainServer) Transactions(w http.ResponseWriter, req *http.Reque
 			io.WriteString(w, string(utils.JsonStatus("fail")))
 			return
 		}
-		publicKey := utils.PublicKeyFromString(*t.SenderPublicKey)
-		signature := utils.SignatureFromString(*t.Signature)
+		publicKey, signature, err := t.KeyAndSignature()
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.Header().Add("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
 		bc := bcs.GetBlockchain()
-		isUpdated := bc.AddTransaction(*t.SenderBlockchainAddress,
-			*t.RecipientBlockchainAddress, *t.Value, publicKey, signature)
+		err = bc.AddTransaction(*t.SenderBlockchainAddress,
+			*t.RecipientBlockchainAddress, *t.Value, t.FeeAmount(), *t.Nonce, publicKey, signature)
 
 		w.Header().Add("Content-Type", "application/json")
 		var m []byte
-		if !isUpdated {
+		if err != nil {
+			log.Printf("ERROR: %v", err)
 			w.WriteHeader(http.StatusBadRequest)
-			m = utils.JsonStatus("fail")
+			m = utils.JsonError(err)
 		} else {
 			m = utils.JsonStatus("success")
 		}
 		io.WriteString(w, string(m))
-	case http.MethodDelete:
-		bc := bcs.GetBlockchain()
-		bc.ClearTransactionPool()
-		io.WriteString(w, string(utils.JsonStatus("success")))
 	default:
 		log.Println("ERROR: Invalid HTTP Method")
 		w.WriteHeader(http.StatusBadRequest)
@@ -142,7 +292,7 @@ func (bcs *BlockchainServer) Mine(w http.ResponseWriter, req *http.Request) {
 	switch req.Method {
 	case http.MethodGet:
 		bc := bcs.GetBlockchain()
-		isMined := bc.Mining()
+		isMined := bc.Mining(req.Context())
 
 		var m []byte
 		if !isMined {
@@ -177,13 +327,29 @@ func (bcs *BlockchainServer) StartMine(w http.ResponseWriter, req *http.Request)
 	}
 }
 
+func (bcs *BlockchainServer) StopMine(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		bc := bcs.GetBlockchain()
+		bc.StopMining()
+
+		m := utils.JsonStatus("success")
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, string(m))
+
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
 func (bcs *BlockchainServer) Amount(w http.ResponseWriter, req *http.Request) {
 	switch req.Method {
 	case http.MethodGet:
 		blockchainAddress := req.URL.Query().Get("blockchain_address")
 		amount := bcs.GetBlockchain().CalculateTotalAmount(blockchainAddress)
 
-		ar := &block.AmountResponse{amount}
+		ar := &block.AmountResponse{Amount: amount}
 		m, _ := ar.MarshalJSON()
 
 		w.Header().Add("Content-Type", "application/json")
@@ -195,6 +361,86 @@ func (bcs *BlockchainServer) Amount(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func (bcs *BlockchainServer) Nonce(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		blockchainAddress := req.URL.Query().Get("blockchain_address")
+		nonce := bcs.GetBlockchain().NextNonce(blockchainAddress)
+
+		m, _ := json.Marshal(&block.NonceResponse{Nonce: nonce})
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, string(m[:]))
+
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// Supply melaporkan jumlah koin yang beredar, batas supply dan jadwal
+// subsidy block berikutnya.
+func (bcs *BlockchainServer) Supply(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		m, _ := json.Marshal(bcs.GetBlockchain().Supply())
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, string(m[:]))
+
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (bcs *BlockchainServer) MerkleProof(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		h, err := hex.DecodeString(req.URL.Query().Get("transaction_hash"))
+		if err != nil || len(h) != 32 {
+			log.Println("ERROR: Invalid transaction hash")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		var transactionHash [32]byte
+		copy(transactionHash[:], h)
+
+		w.Header().Add("Content-Type", "application/json")
+		proof, ok := bcs.GetBlockchain().TransactionProof(transactionHash)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		m, _ := proof.MarshalJSON()
+		io.WriteString(w, string(m[:]))
+
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (bcs *BlockchainServer) Miner(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		m, _ := json.Marshal(struct {
+			BlockchainAddress string `json:"blockchain_address"`
+		}{
+			BlockchainAddress: bcs.GetBlockchain().BlockchainAddress(),
+		})
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, string(m[:]))
+
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
 func (bcs *BlockchainServer) Consensus(w http.ResponseWriter, req *http.Request) {
 	switch req.Method {
 	case http.MethodPut:
@@ -215,6 +461,47 @@ func (bcs *BlockchainServer) Consensus(w http.ResponseWriter, req *http.Request)
 	}
 }
 
+// authorizeAdmin memastikan request membawa header
+// "Authorization: Bearer <admin token>". Tanpa admin token yang dikonfigurasi
+// semua request admin ditolak.
+func (bcs *BlockchainServer) authorizeAdmin(w http.ResponseWriter, req *http.Request) bool {
+	if bcs.adminToken == "" {
+		log.Println("ERROR: Admin API is disabled")
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, string(utils.JsonStatus("fail")))
+		return false
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(bcs.adminToken)) != 1 {
+		log.Println("ERROR: Unauthorized admin request")
+		w.Header().Add("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, string(utils.JsonStatus("fail")))
+		return false
+	}
+	return true
+}
+
+// AdminTransactions mengosongkan mempool node ini. Peer tidak lagi
+// menghapus pool satu sama lain; pool dibersihkan otomatis dari transaksi
+// yang masuk block baru.
+func (bcs *BlockchainServer) AdminTransactions(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodDelete:
+		if !bcs.authorizeAdmin(w, req) {
+			return
+		}
+		bc := bcs.GetBlockchain()
+		bc.ClearTransactionPool()
+		log.Println("Admin: transaction pool cleared")
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, string(utils.JsonStatus("success")))
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
 func (bcs *BlockchainServer) Run() {
 	bc := bcs.GetBlockchain() // Simpan instance Blockchain
 	if bc == nil {
@@ -224,11 +511,20 @@ func (bcs *BlockchainServer) Run() {
 	bcs.GetBlockchain().Run()
 
 	http.HandleFunc("/", bcs.GetChain)
+	http.HandleFunc("/chain/tip", bcs.ChainTip)
+	http.HandleFunc("/chain/headers", bcs.ChainHeaders)
+	http.HandleFunc("/chain/block", bcs.ChainBlock)
 	http.HandleFunc("/transactions", bcs.Transactions)
 	http.HandleFunc("/mine", bcs.Mine)
 	http.HandleFunc("/mine/start", bcs.StartMine)
+	http.HandleFunc("/mine/stop", bcs.StopMine)
 	http.HandleFunc("/amount", bcs.Amount)
+	http.HandleFunc("/nonce", bcs.Nonce)
+	http.HandleFunc("/supply", bcs.Supply)
+	http.HandleFunc("/merkle/proof", bcs.MerkleProof)
 	http.HandleFunc("/consensus", bcs.Consensus)
+	http.HandleFunc("/miner", bcs.Miner)
+	http.HandleFunc("/admin/transactions", bcs.AdminTransactions)
 
 	log.Fatal(http.ListenAndServe("0.0.0.0:"+strconv.Itoa(int(bcs.port)), nil))
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizeAdmin(t *testing.T) {
+	const token = "s3cret-admin-token"
+	tests := []struct {
+		name          string
+		adminToken    string
+		authorization string
+		wantCode      int
+	}{
+		{"no token configured", "", "Bearer " + token, http.StatusForbidden},
+		{"no token configured and none sent", "", "", http.StatusForbidden},
+		{"missing token", token, "", http.StatusUnauthorized},
+		{"wrong token", token, "Bearer wrong", http.StatusUnauthorized},
+		{"token prefix", token, "Bearer " + token[:5], http.StatusUnauthorized},
+		{"empty bearer", token, "Bearer ", http.StatusUnauthorized},
+		{"basic scheme", token, "Basic " + token, http.StatusUnauthorized},
+		{"correct token", token, "Bearer " + token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bcs := NewBlockchainServer(0, "", "", "", 0, tt.adminToken)
+			called := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if bcs.authorizeAdmin(w, req) {
+					called = true
+					io.WriteString(w, "ok")
+				}
+			}))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodDelete, server.URL+"/admin/transactions", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if called != (tt.wantCode == http.StatusOK) {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCode == http.StatusOK)
+			}
+			if tt.wantCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q, want Bearer", resp.Header.Get("WWW-Authenticate"))
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if tt.wantCode != http.StatusOK && !strings.Contains(string(body), "fail") {
+				t.Errorf("body = %s, want a fail status", body)
+			}
+		})
+	}
+}
@@ -3,7 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"learn-blockchain/block"
+	"learn-blockchain/utils"
 	"log"
+	"os"
 )
 
 func init() {
@@ -12,9 +15,20 @@ func init() {
 
 func main() {
 	port := flag.Uint("port", 5000, "TCP Port number for blockchain server")
+	dataDir := flag.String("datadir", "data", "Directory for chain storage (empty keeps the chain in memory)")
+	minerKey := flag.String("miner-key", "", "Miner key file, created on first start (default <datadir>/<port>/miner.key)")
+	rewardAddress := flag.String("reward-address", "", "External blockchain address that receives mining rewards")
+	coinbaseMaturity := flag.Int("coinbase-maturity", block.COINBASE_MATURITY, "Confirmations before a mining reward can be spent (must match the rest of the network)")
+	adminToken := flag.String("admin-token", "", "Bearer token for the /admin API (default $BLOCKCHAIN_ADMIN_TOKEN, empty disables it)")
 	flag.Parse()
+	if *adminToken == "" {
+		*adminToken = os.Getenv("BLOCKCHAIN_ADMIN_TOKEN")
+	}
+	if *rewardAddress != "" && !utils.ValidBlockchainAddress(*rewardAddress) {
+		log.Fatalf("Invalid -reward-address %q", *rewardAddress)
+	}
 
-	app := NewBlockchainServer(uint16(*port))
+	app := NewBlockchainServer(uint16(*port), *dataDir, *minerKey, *rewardAddress, *coinbaseMaturity, *adminToken)
 	fmt.Println("Server running on port", app.Port())
 	app.Run()
 }
@@ -3,6 +3,8 @@ module learn-blockchain
 go 1.21.3
 
 require (
-	github.com/btcsuite/btcutil v1.0.2 // indirect
-	golang.org/x/crypto v0.33.0 // indirect
+	github.com/btcsuite/btcutil v1.0.2
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.3.0
+	github.com/tyler-smith/go-bip39 v1.1.0
+	golang.org/x/crypto v0.33.0
 )
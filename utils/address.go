@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// BlockchainAddressFromPublicKey menurunkan blockchain address dari public key
+// P-256. Koordinat ditulis tanpa padding agar address lama tetap sama.
+func BlockchainAddressFromPublicKey(publicKey *ecdsa.PublicKey) string {
+	b := append(publicKey.X.Bytes(), publicKey.Y.Bytes()...)
+	return blockchainAddressFromBytes(b)
+}
+
+// BlockchainAddress menurunkan blockchain address untuk public key dari
+// scheme manapun. Untuk secp256k1 hasilnya sama dengan address P2PKH Bitcoin
+// dari compressed public key.
+func BlockchainAddress(v Verifier) string {
+	if p, ok := v.(*p256Verifier); ok {
+		return BlockchainAddressFromPublicKey(p.publicKey)
+	}
+	return blockchainAddressFromBytes(v.Bytes())
+}
+
+// blockchainAddressFromBytes: SHA-256 -> RIPEMD-160 -> version byte ->
+// checksum double SHA-256 -> base58.
+func blockchainAddressFromBytes(publicKey []byte) string {
+	h2 := sha256.New()
+	h2.Write(publicKey)
+	digest2 := h2.Sum(nil)
+
+	h3 := ripemd160.New()
+	h3.Write(digest2)
+	digest3 := h3.Sum(nil)
+
+	vd4 := make([]byte, 21)
+	vd4[0] = 0x00
+	copy(vd4[1:], digest3[:])
+
+	h5 := sha256.New()
+	h5.Write(vd4)
+	digest5 := h5.Sum(nil)
+
+	h6 := sha256.New()
+	h6.Write(digest5)
+	digest6 := h6.Sum(nil)
+
+	chsum := digest6[:4]
+
+	dc8 := make([]byte, 25)
+	copy(dc8[:21], vd4[:])
+	copy(dc8[21:], chsum[:])
+
+	return base58.Encode(dc8)
+}
+
+// ValidBlockchainAddress memeriksa bahwa address adalah base58 25 byte
+// dengan version byte 0x00 dan checksum yang cocok.
+func ValidBlockchainAddress(address string) bool {
+	b := base58.Decode(address)
+	if len(b) != 25 || b[0] != 0x00 {
+		return false
+	}
+	digest := sha256.Sum256(b[:21])
+	digest = sha256.Sum256(digest[:])
+	return bytes.Equal(b[21:], digest[:4])
+}
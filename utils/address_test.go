@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestValidBlockchainAddress(t *testing.T) {
+	signer, err := GenerateSigner(SCHEME_P256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := BlockchainAddress(signer.Public())
+
+	tests := []struct {
+		name    string
+		address string
+		want    bool
+	}{
+		{"derived address", address, true},
+		// Address P2PKH genesis Bitcoin memakai format yang sama
+		{"bitcoin address", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true},
+		{"empty", "", false},
+		{"not base58", "0OIl", false},
+		{"bad checksum", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", false},
+		{"truncated", address[:len(address)-2], false},
+		{"other version", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidBlockchainAddress(tt.address); got != tt.want {
+				t.Errorf("ValidBlockchainAddress(%q) = %v, want %v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,46 +3,192 @@ package utils
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"encoding/asn1"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 )
 
+// Semua key dan signature memakai kurva P-256. Encoding kanonik adalah hex
+// dengan lebar tetap: public key X || Y (128 karakter), signature R || S
+// (128 karakter) dan private key D (64 karakter). Parser juga menerima
+// public key SEC1 (compressed/uncompressed) dan signature DER.
+const (
+	KEY_COORDINATE_SIZE = 32
+	PUBLIC_KEY_HEX_LEN  = 4 * KEY_COORDINATE_SIZE
+	SIGNATURE_HEX_LEN   = 4 * KEY_COORDINATE_SIZE
+	PRIVATE_KEY_HEX_LEN = 2 * KEY_COORDINATE_SIZE
+)
+
+var (
+	ErrInvalidPublicKey  = errors.New("invalid public key")
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+	ErrInvalidSignature  = errors.New("invalid signature encoding")
+)
+
 type Signature struct {
 	R *big.Int
 	S *big.Int
 }
 
 func (s *Signature) String() string {
-	return fmt.Sprintf("%x%x", s.R, s.S)
+	return hex.EncodeToString(s.Bytes())
+}
+
+// Bytes adalah R || S masing-masing 32 byte.
+func (s *Signature) Bytes() []byte {
+	b := make([]byte, 2*KEY_COORDINATE_SIZE)
+	s.R.FillBytes(b[:KEY_COORDINATE_SIZE])
+	s.S.FillBytes(b[KEY_COORDINATE_SIZE:])
+	return b
 }
 
-func String2BigIntTuple(s string) (big.Int, big.Int) {
-	bx, _ := hex.DecodeString(s[:64])
-	by, _ := hex.DecodeString(s[64:])
+type derSignature struct {
+	R, S *big.Int
+}
 
-	var bix big.Int
-	var biy big.Int
+// DER mengembalikan encoding ASN.1 DER SEQUENCE { r INTEGER, s INTEGER }.
+func (s *Signature) DER() []byte {
+	b, _ := asn1.Marshal(derSignature{R: s.R, S: s.S})
+	return b
+}
 
-	_ = bix.SetBytes(bx)
-	_ = biy.SetBytes(by)
+// String2BigIntTuple mengurai dua bilangan 32 byte dari 128 karakter hex.
+func String2BigIntTuple(s string) (*big.Int, *big.Int, error) {
+	if len(s) != 4*KEY_COORDINATE_SIZE {
+		return nil, nil, fmt.Errorf("expected %d hex characters, got %d", 4*KEY_COORDINATE_SIZE, len(s))
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	x := new(big.Int).SetBytes(b[:KEY_COORDINATE_SIZE])
+	y := new(big.Int).SetBytes(b[KEY_COORDINATE_SIZE:])
+	return x, y, nil
+}
 
-	return bix, biy
+// validScalar memastikan 0 < v < N.
+func validScalar(v *big.Int) bool {
+	return v.Sign() > 0 && v.Cmp(elliptic.P256().Params().N) < 0
 }
 
-func SignatureFromString(s string) *Signature {
-	x, y := String2BigIntTuple(s)
-	return &Signature{&x, &y}
+// SignatureFromString menerima signature R || S (128 karakter hex) atau
+// signature DER dalam hex.
+func SignatureFromString(s string) (*Signature, error) {
+	var sig *Signature
+	if len(s) == SIGNATURE_HEX_LEN {
+		r, ss, err := String2BigIntTuple(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		sig = &Signature{R: r, S: ss}
+	} else {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		if sig, err = SignatureFromDER(b); err != nil {
+			return nil, err
+		}
+	}
+	if !validScalar(sig.R) || !validScalar(sig.S) {
+		return nil, fmt.Errorf("%w: r or s out of range", ErrInvalidSignature)
+	}
+	return sig, nil
 }
 
-func PublicKeyFromString(s string) *ecdsa.PublicKey {
-	x, y := String2BigIntTuple(s)
-	return &ecdsa.PublicKey{elliptic.P256(), &x, &y}
+// SignatureFromDER mengurai signature ASN.1 DER.
+func SignatureFromDER(b []byte) (*Signature, error) {
+	var v derSignature
+	rest, err := asn1.Unmarshal(b, &v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data after DER signature", ErrInvalidSignature)
+	}
+	if v.R == nil || v.S == nil || !validScalar(v.R) || !validScalar(v.S) {
+		return nil, fmt.Errorf("%w: r or s out of range", ErrInvalidSignature)
+	}
+	return &Signature{R: v.R, S: v.S}, nil
+}
+
+// PublicKeyString adalah encoding kanonik X || Y dengan lebar tetap.
+func PublicKeyString(publicKey *ecdsa.PublicKey) string {
+	b := make([]byte, 2*KEY_COORDINATE_SIZE)
+	publicKey.X.FillBytes(b[:KEY_COORDINATE_SIZE])
+	publicKey.Y.FillBytes(b[KEY_COORDINATE_SIZE:])
+	return hex.EncodeToString(b)
+}
+
+// CompressedPublicKeyString adalah encoding SEC1 compressed (33 byte) dalam hex.
+func CompressedPublicKeyString(publicKey *ecdsa.PublicKey) string {
+	return hex.EncodeToString(elliptic.MarshalCompressed(elliptic.P256(), publicKey.X, publicKey.Y))
+}
+
+// PublicKeyFromString menerima X || Y (128 karakter hex), SEC1 uncompressed
+// (04 || X || Y) atau SEC1 compressed (02/03 || X). Titik yang tidak berada
+// di kurva P-256 ditolak.
+func PublicKeyFromString(s string) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+
+	var x, y *big.Int
+	switch {
+	case len(b) == 2*KEY_COORDINATE_SIZE:
+		x = new(big.Int).SetBytes(b[:KEY_COORDINATE_SIZE])
+		y = new(big.Int).SetBytes(b[KEY_COORDINATE_SIZE:])
+	case len(b) == 1+2*KEY_COORDINATE_SIZE && b[0] == 0x04:
+		x = new(big.Int).SetBytes(b[1 : 1+KEY_COORDINATE_SIZE])
+		y = new(big.Int).SetBytes(b[1+KEY_COORDINATE_SIZE:])
+	case len(b) == 1+KEY_COORDINATE_SIZE && (b[0] == 0x02 || b[0] == 0x03):
+		if x, y = elliptic.UnmarshalCompressed(curve, b); x == nil {
+			return nil, fmt.Errorf("%w: point is not on curve", ErrInvalidPublicKey)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported length %d", ErrInvalidPublicKey, len(s))
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("%w: point is not on curve", ErrInvalidPublicKey)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// PrivateKeyFromString mengurai D dalam hex (maksimal 64 karakter; key lama
+// yang ditulis tanpa padding juga diterima) dan memastikan D cocok dengan
+// publicKey.
+func PrivateKeyFromString(s string, publicKey *ecdsa.PublicKey) (*ecdsa.PrivateKey, error) {
+	if len(s) == 0 || len(s) > PRIVATE_KEY_HEX_LEN {
+		return nil, fmt.Errorf("%w: unsupported length %d", ErrInvalidPrivateKey, len(s))
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	d := new(big.Int).SetBytes(b)
+	if !validScalar(d) {
+		return nil, fmt.Errorf("%w: out of range", ErrInvalidPrivateKey)
+	}
+
+	curve := elliptic.P256()
+	x, y := curve.ScalarBaseMult(d.FillBytes(make([]byte, KEY_COORDINATE_SIZE)))
+	if publicKey != nil && (x.Cmp(publicKey.X) != 0 || y.Cmp(publicKey.Y) != 0) {
+		return nil, fmt.Errorf("%w: does not match public key", ErrInvalidPrivateKey)
+	}
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		D:         d,
+	}, nil
 }
 
-func PrivateKeyFromString(s string, publicKey *ecdsa.PublicKey) *ecdsa.PrivateKey {
-	b, _ := hex.DecodeString(s[:])
-	var bi big.Int
-	_ = bi.SetBytes(b)
-	return &ecdsa.PrivateKey{*publicKey, &bi}
+// PrivateKeyString adalah encoding kanonik D dengan lebar tetap.
+func PrivateKeyString(privateKey *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(privateKey.D.FillBytes(make([]byte, KEY_COORDINATE_SIZE)))
 }
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Encoder menulis nilai dalam format biner kanonik: integer big-endian dengan
+// lebar tetap dan string/bytes dengan prefix panjang uint32. Urutan field
+// ditentukan oleh pemanggil, bukan oleh reflection seperti encoding/json.
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+func NewEncoder() *Encoder {
+	return new(Encoder)
+}
+
+func (e *Encoder) WriteUint8(v uint8) {
+	e.buf.WriteByte(v)
+}
+
+func (e *Encoder) WriteUint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *Encoder) WriteUint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *Encoder) WriteInt64(v int64) {
+	e.WriteUint64(uint64(v))
+}
+
+// WriteFixed menulis bytes tanpa prefix panjang, untuk field berukuran tetap
+// seperti hash.
+func (e *Encoder) WriteFixed(b []byte) {
+	e.buf.Write(b)
+}
+
+func (e *Encoder) WriteBytes(b []byte) {
+	e.WriteUint32(uint32(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *Encoder) WriteString(s string) {
+	e.WriteBytes([]byte(s))
+}
+
+func (e *Encoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
@@ -10,3 +10,14 @@ func JsonStatus(message string) []byte {
 	})
 	return m
 }
+
+func JsonError(err error) []byte {
+	m, _ := json.Marshal(struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}{
+		Message: "fail",
+		Error:   err.Error(),
+	})
+	return m
+}
@@ -5,11 +5,12 @@ import (
 	"log"
 	"net"
 	"regexp"
+	"strconv"
 	"time"
 )
 
 func IsFoundHost(host string, port uint16) bool {
-	target := fmt.Sprintf("%s:%d", host, port)
+	target := net.JoinHostPort(host, strconv.Itoa(int(port)))
 	log.Printf("Attempting to connect to %s", target)
 
 	_, err := net.DialTimeout("tcp", target, 1*time.Second)
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// SignDeterministic menandatangani hash dengan nonce k dari RFC 6979
+// (HMAC-SHA256), sehingga key dan hash yang sama selalu menghasilkan
+// signature yang sama. Hasilnya sudah dinormalisasi ke low-S.
+//
+// Implementasi ini TIDAK constant-time: aritmetika modular memakai math/big,
+// yang waktunya bergantung pada nilai k dan private key. Penyerang yang bisa
+// mengukur waktu banyak penandatanganan (mis. lewat endpoint wallet server
+// di jaringan yang sama) berpotensi memulihkan private key. Key secp256k1
+// dan Ed25519 tidak melewati fungsi ini dan memakai implementasi
+// constant-time dari library masing-masing.
+func SignDeterministic(privateKey *ecdsa.PrivateKey, hash []byte) (*Signature, error) {
+	curve := privateKey.Curve
+	n := curve.Params().N
+	if privateKey.D == nil || privateKey.D.Sign() <= 0 || privateKey.D.Cmp(n) >= 0 {
+		return nil, errors.New("invalid private key")
+	}
+
+	e := hashToInt(hash, n)
+	nonces := newRFC6979(privateKey.D, hash, n)
+	for {
+		k := nonces.next()
+		x, _ := curve.ScalarBaseMult(k.FillBytes(make([]byte, nonces.rolen)))
+		r := new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k^-1 (e + r*d) mod n
+		s := new(big.Int).Mul(r, privateKey.D)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+		sig := &Signature{R: r, S: s}
+		sig.Normalize(curve)
+		return sig, nil
+	}
+}
+
+// rfc6979 menghasilkan kandidat k sesuai RFC 6979 bagian 3.2. Kandidat
+// berikutnya hanya dipakai bila kandidat sebelumnya menghasilkan r atau s nol.
+type rfc6979 struct {
+	n     *big.Int
+	rolen int
+	k     []byte
+	v     []byte
+	first bool
+}
+
+func newRFC6979(d *big.Int, hash []byte, n *big.Int) *rfc6979 {
+	rolen := (n.BitLen() + 7) / 8
+	x := d.FillBytes(make([]byte, rolen))
+	h1 := new(big.Int).Mod(hashToInt(hash, n), n).FillBytes(make([]byte, rolen))
+
+	g := &rfc6979{n: n, rolen: rolen, first: true}
+	g.v = make([]byte, sha256.Size)
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+	g.k = make([]byte, sha256.Size)
+	g.k = g.mac(g.k, g.v, []byte{0x00}, x, h1)
+	g.v = g.mac(g.k, g.v)
+	g.k = g.mac(g.k, g.v, []byte{0x01}, x, h1)
+	g.v = g.mac(g.k, g.v)
+	return g
+}
+
+func (g *rfc6979) mac(key []byte, data ...[]byte) []byte {
+	m := hmac.New(sha256.New, key)
+	for _, b := range data {
+		m.Write(b)
+	}
+	return m.Sum(nil)
+}
+
+func (g *rfc6979) next() *big.Int {
+	for {
+		if !g.first {
+			g.k = g.mac(g.k, g.v, []byte{0x00})
+			g.v = g.mac(g.k, g.v)
+		}
+		g.first = false
+
+		var t []byte
+		for len(t) < g.rolen {
+			g.v = g.mac(g.k, g.v)
+			t = append(t, g.v...)
+		}
+		candidate := hashToInt(t[:g.rolen], g.n)
+		if candidate.Sign() > 0 && candidate.Cmp(g.n) < 0 {
+			return candidate
+		}
+	}
+}
+
+// hashToInt mengambil bit paling kiri hash sepanjang bit order kurva
+// (bits2int pada RFC 6979).
+func hashToInt(hash []byte, n *big.Int) *big.Int {
+	orderBytes := (n.BitLen() + 7) / 8
+	if len(hash) > orderBytes {
+		hash = hash[:orderBytes]
+	}
+	ret := new(big.Int).SetBytes(hash)
+	if excess := len(hash)*8 - n.BitLen(); excess > 0 {
+		ret.Rsh(ret, uint(excess))
+	}
+	return ret
+}
+
+// halfOrder mengembalikan N/2 untuk kurva.
+func halfOrder(curve elliptic.Curve) *big.Int {
+	return new(big.Int).Rsh(curve.Params().N, 1)
+}
+
+// IsLowS melaporkan apakah S <= N/2. Untuk setiap signature (R, S) yang
+// valid, (R, N-S) juga valid; hanya bentuk low-S yang diterima agar ID
+// transaksi tidak bisa diubah pihak ketiga.
+func (s *Signature) IsLowS(curve elliptic.Curve) bool {
+	return s.S.Cmp(halfOrder(curve)) <= 0
+}
+
+// Normalize mengubah signature ke bentuk low-S.
+func (s *Signature) Normalize(curve elliptic.Curve) {
+	if !s.IsLowS(curve) {
+		s.S = new(big.Int).Sub(curve.Params().N, s.S)
+	}
+}
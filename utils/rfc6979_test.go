@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+func hexInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		t.Fatalf("invalid hex %q", s)
+	}
+	return v
+}
+
+// Test vector RFC 6979 A.2.5 (P-256, SHA-256). Signature yang diharapkan
+// adalah versi low-S dari signature di RFC.
+func TestSignDeterministicVectors(t *testing.T) {
+	curve := elliptic.P256()
+	d := hexInt(t, "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	privateKey := new(ecdsa.PrivateKey)
+	privateKey.Curve = curve
+	privateKey.D = d
+	privateKey.X, privateKey.Y = curve.ScalarBaseMult(d.Bytes())
+
+	tests := []struct {
+		message string
+		r, s    string
+	}{
+		{"sample",
+			"EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+			"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8"},
+		{"test",
+			"F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+			"019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			hash := sha256.Sum256([]byte(tt.message))
+			sig, err := SignDeterministic(privateKey, hash[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := &Signature{R: hexInt(t, tt.r), S: hexInt(t, tt.s)}
+			want.Normalize(curve)
+			if sig.R.Cmp(want.R) != 0 || sig.S.Cmp(want.S) != 0 {
+				t.Errorf("signature = %s, want %s", sig, want)
+			}
+			if !ecdsa.Verify(&privateKey.PublicKey, hash[:], sig.R, sig.S) {
+				t.Error("signature does not verify")
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	half := halfOrder(curve)
+	tests := []struct {
+		name string
+		s    *big.Int
+		want *big.Int
+	}{
+		{"one", big.NewInt(1), big.NewInt(1)},
+		{"half order", half, half},
+		{"half order plus one", new(big.Int).Add(half, big.NewInt(1)), half},
+		{"order minus one", new(big.Int).Sub(n, big.NewInt(1)), big.NewInt(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := &Signature{R: big.NewInt(1), S: new(big.Int).Set(tt.s)}
+			sig.Normalize(curve)
+			if sig.S.Cmp(tt.want) != 0 {
+				t.Errorf("Normalize() S = %x, want %x", sig.S, tt.want)
+			}
+			if !sig.IsLowS(curve) {
+				t.Error("normalized signature is not low-S")
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsHighS(t *testing.T) {
+	orders := map[Scheme]*big.Int{
+		SCHEME_P256:      elliptic.P256().Params().N,
+		SCHEME_SECP256K1: secp256k1.S256().Params().N,
+	}
+	for scheme, n := range orders {
+		t.Run(string(scheme), func(t *testing.T) {
+			signer, err := GenerateSigner(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 8; i++ {
+				hash := sha256.Sum256([]byte{byte(i)})
+				signature, err := signer.Sign(hash[:])
+				if err != nil {
+					t.Fatal(err)
+				}
+				again, _ := signer.Sign(hash[:])
+				if string(again) != string(signature) {
+					t.Fatal("signing the same hash twice gave different signatures")
+				}
+				if err := signer.Public().Verify(hash[:], signature); err != nil {
+					t.Fatalf("low-S signature rejected: %v", err)
+				}
+
+				// (R, N-S) juga valid secara matematis tetapi harus ditolak
+				s := new(big.Int).SetBytes(signature[32:])
+				flipped := append([]byte(nil), signature[:32]...)
+				flipped = append(flipped, new(big.Int).Sub(n, s).FillBytes(make([]byte, 32))...)
+				if err := signer.Public().Verify(hash[:], flipped); !errors.Is(err, ErrMalleableSignature) {
+					t.Errorf("high-S signature error = %v, want %v", err, ErrMalleableSignature)
+				}
+			}
+		})
+	}
+}
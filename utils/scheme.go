@@ -0,0 +1,331 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Scheme mengidentifikasi algoritma signature sebuah key. Nilai kosong pada
+// request atau file lama berarti P-256.
+type Scheme string
+
+const (
+	SCHEME_P256      Scheme = "p256"
+	SCHEME_SECP256K1 Scheme = "secp256k1"
+	SCHEME_ED25519   Scheme = "ed25519"
+
+	// Semua scheme memakai signature 64 byte: R || S untuk ECDSA, encoding
+	// standar untuk Ed25519.
+	SIGNATURE_SIZE = 64
+)
+
+var (
+	ErrUnknownScheme      = errors.New("unknown signature scheme")
+	ErrMalleableSignature = errors.New("signature is not in canonical low-S form")
+	ErrSignatureMismatch  = errors.New("signature does not verify")
+)
+
+// ParseScheme mengurai nama scheme; string kosong berarti P-256.
+func ParseScheme(s string) (Scheme, error) {
+	switch Scheme(s) {
+	case "", SCHEME_P256:
+		return SCHEME_P256, nil
+	case SCHEME_SECP256K1, SCHEME_ED25519:
+		return Scheme(s), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownScheme, s)
+}
+
+// Verifier adalah public key dari scheme manapun.
+type Verifier interface {
+	Scheme() Scheme
+	// Bytes adalah encoding kanonik public key: X || Y untuk P-256, SEC1
+	// compressed untuk secp256k1 dan 32 byte untuk Ed25519.
+	Bytes() []byte
+	// Verify memeriksa signature atas hash dan menolak signature yang
+	// malleable (ErrMalleableSignature).
+	Verify(hash []byte, signature []byte) error
+}
+
+// Signer adalah private key dari scheme manapun.
+type Signer interface {
+	Scheme() Scheme
+	Public() Verifier
+	// Bytes adalah private key 32 byte (scalar ECDSA atau seed Ed25519).
+	Bytes() []byte
+	// Sign menandatangani hash secara deterministik.
+	Sign(hash []byte) ([]byte, error)
+}
+
+// GenerateSigner membuat private key acak untuk scheme.
+func GenerateSigner(scheme Scheme) (Signer, error) {
+	switch scheme {
+	case SCHEME_P256:
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewP256Signer(privateKey), nil
+	case SCHEME_SECP256K1:
+		privateKey, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		return &secp256k1Signer{privateKey}, nil
+	case SCHEME_ED25519:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519Signer(privateKey), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, scheme)
+}
+
+// SignerFromBytes memuat private key 32 byte untuk scheme.
+func SignerFromBytes(scheme Scheme, b []byte) (Signer, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("%w: expected 32 bytes, got %d", ErrInvalidPrivateKey, len(b))
+	}
+	switch scheme {
+	case SCHEME_P256:
+		privateKey, err := PrivateKeyFromString(hex.EncodeToString(b), nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewP256Signer(privateKey), nil
+	case SCHEME_SECP256K1:
+		var d secp256k1.ModNScalar
+		if overflow := d.SetByteSlice(b); overflow || d.IsZero() {
+			return nil, fmt.Errorf("%w: out of range", ErrInvalidPrivateKey)
+		}
+		return &secp256k1Signer{secp256k1.NewPrivateKey(&d)}, nil
+	case SCHEME_ED25519:
+		return ed25519Signer(ed25519.NewKeyFromSeed(b)), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, scheme)
+}
+
+// SignerFromString memuat private key dalam hex untuk scheme.
+func SignerFromString(scheme Scheme, s string) (Signer, error) {
+	if scheme == SCHEME_P256 {
+		// Key P-256 lama bisa tersimpan tanpa padding
+		privateKey, err := PrivateKeyFromString(s, nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewP256Signer(privateKey), nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	return SignerFromBytes(scheme, b)
+}
+
+// ParsePublicKey mengurai public key dalam hex untuk scheme. P-256 dan
+// secp256k1 menerima SEC1 compressed maupun uncompressed.
+func ParsePublicKey(scheme Scheme, s string) (Verifier, error) {
+	switch scheme {
+	case SCHEME_P256:
+		publicKey, err := PublicKeyFromString(s)
+		if err != nil {
+			return nil, err
+		}
+		return NewP256Verifier(publicKey), nil
+	case SCHEME_SECP256K1:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+		}
+		publicKey, err := secp256k1.ParsePubKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+		}
+		return &secp256k1Verifier{publicKey}, nil
+	case SCHEME_ED25519:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+		}
+		if len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: unsupported length %d", ErrInvalidPublicKey, len(b))
+		}
+		return ed25519Verifier(b), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, scheme)
+}
+
+// ParseSignature mengurai signature dalam hex untuk scheme dan mengembalikan
+// bentuk 64 byte-nya. Signature ECDSA juga boleh dalam format DER.
+func ParseSignature(scheme Scheme, s string) ([]byte, error) {
+	switch scheme {
+	case SCHEME_P256:
+		sig, err := SignatureFromString(s)
+		if err != nil {
+			return nil, err
+		}
+		return sig.Bytes(), nil
+	case SCHEME_SECP256K1:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		if len(b) == SIGNATURE_SIZE {
+			return b, nil
+		}
+		sig, err := secpecdsa.ParseDERSignature(b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		r, ss := sig.R(), sig.S()
+		out := make([]byte, SIGNATURE_SIZE)
+		r.PutBytesUnchecked(out[:32])
+		ss.PutBytesUnchecked(out[32:])
+		return out, nil
+	case SCHEME_ED25519:
+		b, err := hex.DecodeString(s)
+		if err != nil || len(b) != ed25519.SignatureSize {
+			return nil, ErrInvalidSignature
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, scheme)
+}
+
+// P-256. Penandatanganan memakai SignDeterministic yang tidak constant-time;
+// lihat catatan di sana sebelum memakai key P-256 di server yang bisa diukur
+// waktunya oleh pihak lain.
+
+type p256Signer struct{ privateKey *ecdsa.PrivateKey }
+type p256Verifier struct{ publicKey *ecdsa.PublicKey }
+
+func NewP256Signer(privateKey *ecdsa.PrivateKey) Signer {
+	return &p256Signer{privateKey}
+}
+
+func NewP256Verifier(publicKey *ecdsa.PublicKey) Verifier {
+	return &p256Verifier{publicKey}
+}
+
+func (s *p256Signer) Scheme() Scheme   { return SCHEME_P256 }
+func (s *p256Signer) Public() Verifier { return &p256Verifier{&s.privateKey.PublicKey} }
+func (s *p256Signer) Bytes() []byte {
+	return s.privateKey.D.FillBytes(make([]byte, KEY_COORDINATE_SIZE))
+}
+
+func (s *p256Signer) Sign(hash []byte) ([]byte, error) {
+	sig, err := SignDeterministic(s.privateKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Bytes(), nil
+}
+
+func (v *p256Verifier) Scheme() Scheme { return SCHEME_P256 }
+func (v *p256Verifier) Bytes() []byte {
+	b, _ := hex.DecodeString(PublicKeyString(v.publicKey))
+	return b
+}
+
+func (v *p256Verifier) Verify(hash []byte, signature []byte) error {
+	if len(signature) != SIGNATURE_SIZE {
+		return ErrInvalidSignature
+	}
+	sig := &Signature{
+		R: new(big.Int).SetBytes(signature[:32]),
+		S: new(big.Int).SetBytes(signature[32:]),
+	}
+	if !sig.IsLowS(v.publicKey.Curve) {
+		return ErrMalleableSignature
+	}
+	if !ecdsa.Verify(v.publicKey, hash, sig.R, sig.S) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// secp256k1
+
+type secp256k1Signer struct{ privateKey *secp256k1.PrivateKey }
+type secp256k1Verifier struct{ publicKey *secp256k1.PublicKey }
+
+func (s *secp256k1Signer) Scheme() Scheme   { return SCHEME_SECP256K1 }
+func (s *secp256k1Signer) Public() Verifier { return &secp256k1Verifier{s.privateKey.PubKey()} }
+func (s *secp256k1Signer) Bytes() []byte    { return s.privateKey.Serialize() }
+
+// Sign memakai RFC 6979 dan selalu menghasilkan low-S.
+func (s *secp256k1Signer) Sign(hash []byte) ([]byte, error) {
+	sig := secpecdsa.Sign(s.privateKey, hash)
+	r, ss := sig.R(), sig.S()
+	out := make([]byte, SIGNATURE_SIZE)
+	r.PutBytesUnchecked(out[:32])
+	ss.PutBytesUnchecked(out[32:])
+	return out, nil
+}
+
+func (v *secp256k1Verifier) Scheme() Scheme { return SCHEME_SECP256K1 }
+func (v *secp256k1Verifier) Bytes() []byte  { return v.publicKey.SerializeCompressed() }
+
+func (v *secp256k1Verifier) Verify(hash []byte, signature []byte) error {
+	if len(signature) != SIGNATURE_SIZE {
+		return ErrInvalidSignature
+	}
+	var r, s secp256k1.ModNScalar
+	if overflow := r.SetByteSlice(signature[:32]); overflow || r.IsZero() {
+		return ErrInvalidSignature
+	}
+	if overflow := s.SetByteSlice(signature[32:]); overflow || s.IsZero() {
+		return ErrInvalidSignature
+	}
+	if s.IsOverHalfOrder() {
+		return ErrMalleableSignature
+	}
+	if !secpecdsa.NewSignature(&r, &s).Verify(hash, v.publicKey) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// Ed25519. Signature Ed25519 sudah deterministik dan crypto/ed25519 menolak
+// S non-kanonik, jadi tidak perlu normalisasi.
+
+type ed25519Signer ed25519.PrivateKey
+type ed25519Verifier ed25519.PublicKey
+
+func (s ed25519Signer) Scheme() Scheme { return SCHEME_ED25519 }
+func (s ed25519Signer) Public() Verifier {
+	return ed25519Verifier(ed25519.PrivateKey(s).Public().(ed25519.PublicKey))
+}
+func (s ed25519Signer) Bytes() []byte { return ed25519.PrivateKey(s).Seed() }
+
+func (s ed25519Signer) Sign(hash []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(s), hash), nil
+}
+
+func (v ed25519Verifier) Scheme() Scheme { return SCHEME_ED25519 }
+func (v ed25519Verifier) Bytes() []byte  { return append([]byte(nil), v...) }
+
+func (v ed25519Verifier) Verify(hash []byte, signature []byte) error {
+	if len(signature) != ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+	if !ed25519.Verify(ed25519.PublicKey(v), hash, signature) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// PublicKeyHex adalah encoding kanonik public key dalam hex.
+func PublicKeyHex(v Verifier) string {
+	return hex.EncodeToString(v.Bytes())
+}
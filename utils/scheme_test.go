@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+var testSchemes = []Scheme{SCHEME_P256, SCHEME_SECP256K1, SCHEME_ED25519}
+
+func TestSchemeRoundTrip(t *testing.T) {
+	hash := sha256.Sum256([]byte("transaction"))
+	for _, scheme := range testSchemes {
+		t.Run(string(scheme), func(t *testing.T) {
+			signer, err := GenerateSigner(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := SignerFromBytes(scheme, signer.Bytes())
+			if err != nil {
+				t.Fatalf("SignerFromBytes: %v", err)
+			}
+			if PublicKeyHex(loaded.Public()) != PublicKeyHex(signer.Public()) {
+				t.Error("SignerFromBytes loads a different key")
+			}
+
+			publicKey, err := ParsePublicKey(scheme, PublicKeyHex(signer.Public()))
+			if err != nil {
+				t.Fatalf("ParsePublicKey: %v", err)
+			}
+			if BlockchainAddress(publicKey) != BlockchainAddress(signer.Public()) {
+				t.Error("parsed public key has a different address")
+			}
+
+			signature, err := signer.Sign(hash[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(signature) != SIGNATURE_SIZE {
+				t.Errorf("signature length = %d, want %d", len(signature), SIGNATURE_SIZE)
+			}
+			again, _ := signer.Sign(hash[:])
+			if string(again) != string(signature) {
+				t.Error("signing is not deterministic")
+			}
+			if err := publicKey.Verify(hash[:], signature); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+			other := sha256.Sum256([]byte("other"))
+			if err := publicKey.Verify(other[:], signature); !errors.Is(err, ErrSignatureMismatch) {
+				t.Errorf("Verify(other hash) = %v, want ErrSignatureMismatch", err)
+			}
+		})
+	}
+}
+
+func TestParseScheme(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Scheme
+		wantErr bool
+	}{
+		{"", SCHEME_P256, false},
+		{"p256", SCHEME_P256, false},
+		{"secp256k1", SCHEME_SECP256K1, false},
+		{"ed25519", SCHEME_ED25519, false},
+		{"rsa", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseScheme(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseScheme(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestParsePublicKeyInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  Scheme
+		key     string
+		wantMsg string
+	}{
+		{"ed25519 short", SCHEME_ED25519, strings.Repeat("ab", 31), "unsupported length 31"},
+		{"ed25519 long", SCHEME_ED25519, strings.Repeat("ab", 33), "unsupported length 33"},
+		{"ed25519 not hex", SCHEME_ED25519, strings.Repeat("zz", 32), ""},
+		{"secp256k1 not on curve", SCHEME_SECP256K1, "02" + strings.Repeat("00", 32), ""},
+		{"p256 short", SCHEME_P256, "abcd", ""},
+		{"unknown scheme", Scheme("rsa"), "abcd", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePublicKey(tt.scheme, tt.key)
+			if err == nil {
+				t.Fatal("ParsePublicKey succeeded, want error")
+			}
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %q, want it to mention %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSignatureFromStringInvalid(t *testing.T) {
+	n := elliptic.P256().Params().N
+	scalar := func(v *big.Int) string { return hex.EncodeToString(v.FillBytes(make([]byte, KEY_COORDINATE_SIZE))) }
+	one := scalar(big.NewInt(1))
+	der := func(r, s *big.Int, trailing ...byte) string {
+		b, err := asn1.Marshal(derSignature{R: r, S: s})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(append(b, trailing...))
+	}
+
+	if _, err := SignatureFromString(der(big.NewInt(1), big.NewInt(1))); err != nil {
+		t.Fatalf("valid DER signature rejected: %v", err)
+	}
+	tests := []struct {
+		name string
+		sig  string
+	}{
+		{"empty", ""},
+		{"short raw", strings.Repeat("ab", 63)},
+		{"long raw", strings.Repeat("ab", 65)},
+		{"odd length", strings.Repeat("a", SIGNATURE_HEX_LEN+1)},
+		{"raw not hex", strings.Repeat("zz", 64)},
+		{"raw r zero", scalar(big.NewInt(0)) + one},
+		{"raw s zero", one + scalar(big.NewInt(0))},
+		{"raw r equals n", scalar(n) + one},
+		{"raw s above n", one + strings.Repeat("ff", 32)},
+		{"der trailing bytes", der(big.NewInt(1), big.NewInt(1), 0x00)},
+		{"der r zero", der(big.NewInt(0), big.NewInt(1))},
+		{"der s equals n", der(big.NewInt(1), n)},
+		{"der negative r", der(big.NewInt(-1), big.NewInt(1))},
+		{"der oversized s", der(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 300))},
+		{"der truncated", der(big.NewInt(1), big.NewInt(1))[:10]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SignatureFromString(tt.sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("SignatureFromString error = %v, want %v", err, ErrInvalidSignature)
+			}
+			if b, err := hex.DecodeString(tt.sig); err == nil && len(tt.sig) != SIGNATURE_HEX_LEN {
+				if _, err := SignatureFromDER(b); !errors.Is(err, ErrInvalidSignature) {
+					t.Errorf("SignatureFromDER error = %v, want %v", err, ErrInvalidSignature)
+				}
+			}
+		})
+	}
+}
+
+func TestPrivateKeyFromStringInvalid(t *testing.T) {
+	n := elliptic.P256().Params().N
+	other, err := GenerateSigner(SCHEME_P256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := GenerateSigner(SCHEME_P256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		publicKey *ecdsa.PublicKey
+	}{
+		{"empty", "", nil},
+		{"too long", strings.Repeat("01", KEY_COORDINATE_SIZE+1), nil},
+		{"not hex", strings.Repeat("zz", KEY_COORDINATE_SIZE), nil},
+		{"zero", strings.Repeat("00", KEY_COORDINATE_SIZE), nil},
+		{"equals n", hex.EncodeToString(n.Bytes()), nil},
+		{"above n", strings.Repeat("ff", KEY_COORDINATE_SIZE), nil},
+		{"other public key", hex.EncodeToString(signer.Bytes()), &other.(*p256Signer).privateKey.PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PrivateKeyFromString(tt.key, tt.publicKey); !errors.Is(err, ErrInvalidPrivateKey) {
+				t.Errorf("PrivateKeyFromString error = %v, want %v", err, ErrInvalidPrivateKey)
+			}
+		})
+	}
+}
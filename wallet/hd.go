@@ -0,0 +1,187 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Derivasi HD mengikuti SLIP-10 untuk kurva NIST P-256 (BIP-32 hanya
+// mendefinisikan secp256k1). Seed berasal dari mnemonic BIP-39.
+const (
+	HD_HARDENED_OFFSET = uint32(1) << 31
+
+	// Cabang receive untuk akun pertama: m/44'/0'/0'/0/<index>
+	HD_RECEIVE_PATH = "m/44'/0'/0'/0"
+
+	HD_MNEMONIC_ENTROPY_BITS = 256
+
+	hdMasterHMACKey = "Nist256p1 seed"
+)
+
+var (
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	ErrInvalidHDPath   = errors.New("invalid derivation path")
+)
+
+// HDKey adalah extended private key: private key plus chain code.
+type HDKey struct {
+	privateKey *ecdsa.PrivateKey
+	chainCode  []byte
+	depth      uint8
+	index      uint32
+}
+
+// NewMnemonic membuat mnemonic BIP-39 baru (24 kata) dari entropy acak.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(HD_MNEMONIC_ENTROPY_BITS)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// SeedFromMnemonic memvalidasi mnemonic (termasuk checksum) lalu menurunkan
+// seed 64 byte. password adalah passphrase opsional BIP-39.
+func SeedFromMnemonic(mnemonic string, password string) ([]byte, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	return bip39.NewSeed(mnemonic, password), nil
+}
+
+func hdPrivateKey(d *big.Int) *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	privateKey := new(ecdsa.PrivateKey)
+	privateKey.PublicKey.Curve = curve
+	privateKey.D = d
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(d.FillBytes(make([]byte, 32)))
+	return privateKey
+}
+
+// NewMasterKey menurunkan master key dari seed.
+func NewMasterKey(seed []byte) (*HDKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed length must be between 16 and 64 bytes, got %d", len(seed))
+	}
+	n := elliptic.P256().Params().N
+	data := seed
+	for {
+		mac := hmac.New(sha512.New, []byte(hdMasterHMACKey))
+		mac.Write(data)
+		I := mac.Sum(nil)
+		d := new(big.Int).SetBytes(I[:32])
+		if d.Sign() != 0 && d.Cmp(n) < 0 {
+			return &HDKey{privateKey: hdPrivateKey(d), chainCode: I[32:]}, nil
+		}
+		// Kasus yang sangat jarang: ulangi dengan I sebagai input
+		data = I
+	}
+}
+
+// Child menurunkan child key ke-index. Index >= HD_HARDENED_OFFSET adalah
+// hardened derivation.
+func (k *HDKey) Child(index uint32) (*HDKey, error) {
+	if k.depth == 255 {
+		return nil, ErrInvalidHDPath
+	}
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	var data []byte
+	if index >= HD_HARDENED_OFFSET {
+		data = append([]byte{0x00}, k.privateKey.D.FillBytes(make([]byte, 32))...)
+	} else {
+		data = elliptic.MarshalCompressed(curve, k.privateKey.X, k.privateKey.Y)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	for {
+		mac := hmac.New(sha512.New, k.chainCode)
+		mac.Write(data)
+		I := mac.Sum(nil)
+		il := new(big.Int).SetBytes(I[:32])
+		if il.Cmp(n) < 0 {
+			d := il.Add(il, k.privateKey.D)
+			d.Mod(d, n)
+			if d.Sign() != 0 {
+				return &HDKey{
+					privateKey: hdPrivateKey(d),
+					chainCode:  I[32:],
+					depth:      k.depth + 1,
+					index:      index,
+				}, nil
+			}
+		}
+		// SLIP-10: IL tidak valid, ulangi dengan 0x01 || IR || index
+		data = append([]byte{0x01}, I[32:]...)
+		data = binary.BigEndian.AppendUint32(data, index)
+	}
+}
+
+// ParseHDPath mengurai path seperti "m/44'/0'/0'/0/5". Komponen dengan
+// akhiran ' atau h adalah hardened.
+func ParseHDPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, ErrInvalidHDPath
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		hardened := strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h")
+		if hardened {
+			p = p[:len(p)-1]
+		}
+		i, err := strconv.ParseUint(p, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHDPath, path)
+		}
+		index := uint32(i)
+		if hardened {
+			index += HD_HARDENED_OFFSET
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// Derive menurunkan key pada path relatif terhadap master key k.
+func (k *HDKey) Derive(path string) (*HDKey, error) {
+	indexes, err := ParseHDPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := k
+	for _, index := range indexes {
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func (k *HDKey) PrivateKey() *ecdsa.PrivateKey { return k.privateKey }
+func (k *HDKey) ChainCode() []byte             { return k.chainCode }
+func (k *HDKey) Depth() uint8                  { return k.depth }
+func (k *HDKey) Index() uint32                 { return k.index }
+
+// Wallet mengembalikan wallet biasa untuk key ini; address-nya dihitung
+// dengan cara yang sama seperti wallet acak.
+func (k *HDKey) Wallet() *Wallet {
+	return NewWalletFromPrivateKey(k.privateKey)
+}
+
+// ReceivePath adalah path address receive ke-index.
+func ReceivePath(index uint32) string {
+	return fmt.Sprintf("%s/%d", HD_RECEIVE_PATH, index)
+}
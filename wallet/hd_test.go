@@ -0,0 +1,194 @@
+package wallet
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Test vector 1 SLIP-10 untuk kurva nist256p1. Kolom kosong tidak dicek.
+func TestSLIP10Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path       string
+		chainCode  string
+		privateKey string
+		publicKey  string
+	}{
+		{
+			path:       "m",
+			chainCode:  "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea",
+			privateKey: "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2",
+			publicKey:  "0266874dc6ade47b3ecd096745ca09bcd29638dd52c2c12117b11ed3e458cfa9e8",
+		},
+		{
+			path:       "m/0'",
+			privateKey: "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c",
+			publicKey:  "0384610f5ecffe8fda089363a41f56a5c7ffc1d81b59a612d0d649b2d22355590c",
+		},
+		{
+			path:      "m/0'/1",
+			chainCode: "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			key, err := master.Derive(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			privateKey := key.PrivateKey()
+			got := map[string]string{
+				"chain code":  hex.EncodeToString(key.ChainCode()),
+				"private key": hex.EncodeToString(privateKey.D.FillBytes(make([]byte, 32))),
+				"public key":  hex.EncodeToString(elliptic.MarshalCompressed(elliptic.P256(), privateKey.X, privateKey.Y)),
+			}
+			want := map[string]string{
+				"chain code":  tt.chainCode,
+				"private key": tt.privateKey,
+				"public key":  tt.publicKey,
+			}
+			for field, w := range want {
+				if w != "" && got[field] != w {
+					t.Errorf("%s = %s, want %s", field, got[field], w)
+				}
+			}
+		})
+	}
+}
+
+func TestSeedFromMnemonic(t *testing.T) {
+	// Test vector BIP-39 milik Trezor dengan passphrase "TREZOR"
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	seed, err := SeedFromMnemonic("  "+mnemonic+"\n", "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if got := hex.EncodeToString(seed); got != want {
+		t.Errorf("seed = %s, want %s", got, want)
+	}
+
+	for _, bad := range []string{
+		"",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon notaword",
+	} {
+		if _, err := SeedFromMnemonic(bad, ""); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Errorf("SeedFromMnemonic(%q) error = %v, want %v", bad, err, ErrInvalidMnemonic)
+		}
+	}
+}
+
+func TestParseHDPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []uint32
+		err  bool
+	}{
+		{path: "m", want: []uint32{}},
+		{path: "m/0", want: []uint32{0}},
+		{path: "m/44'/0h/5", want: []uint32{44 + HD_HARDENED_OFFSET, HD_HARDENED_OFFSET, 5}},
+		{path: "m/2147483647'", want: []uint32{2147483647 + HD_HARDENED_OFFSET}},
+		{path: "m/2147483648", err: true},
+		{path: "44'/0'", err: true},
+		{path: "m/-1", err: true},
+		{path: "m//1", err: true},
+		{path: "m/x", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseHDPath(tt.path)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidHDPath) {
+					t.Errorf("error = %v, want %v", err, ErrInvalidHDPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseHDPath() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseHDPath() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHDWalletRoundTrip(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hw, err := NewHDWalletFromMnemonic(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, index, err := hw.NextReceive()
+	if err != nil || index != 0 {
+		t.Fatalf("NextReceive() index = %d, err = %v", index, err)
+	}
+	if first.BlockchainAddress() != hw.ID() {
+		t.Error("ID is not the first receive address")
+	}
+	second, _, err := hw.NextReceive()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := SeedPath(t.TempDir(), hw.ID())
+	if err := SaveHDWallet(path, hw, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := hw.NextReceive(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveHDWalletIndex(path, hw); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadHDWallet(path, "passphrase")
+	if err != nil {
+		t.Fatalf("LoadHDWallet: %v", err)
+	}
+	if loaded.ID() != hw.ID() || loaded.NextIndex() != 3 {
+		t.Errorf("loaded wallet %s at index %d, want %s at index 3", loaded.ID(), loaded.NextIndex(), hw.ID())
+	}
+	if w, _ := loaded.Receive(1); w.BlockchainAddress() != second.BlockchainAddress() {
+		t.Error("loaded wallet derives a different receive address")
+	}
+	if _, err := LoadHDWallet(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("LoadHDWallet with wrong passphrase error = %v, want %v", err, ErrWrongPassphrase)
+	}
+
+	// Mnemonic yang sama memulihkan wallet yang sama
+	restored, err := NewHDWalletFromMnemonic(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID() != hw.ID() {
+		t.Error("mnemonic does not restore the same wallet")
+	}
+
+	other, err := NewHDWallet(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveHDWalletIndex(path, other); err == nil {
+		t.Error("SaveHDWalletIndex accepted a seed file of another wallet")
+	}
+	if _, err := NewHDWallet(make([]byte, 8)); err == nil {
+		t.Error("NewHDWallet accepted an 8 byte seed")
+	}
+}
@@ -0,0 +1,147 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HDWallet menyimpan seed dan index receive address berikutnya. Semua
+// address bisa dipulihkan dari mnemonic, jadi cukup satu backup.
+type HDWallet struct {
+	seed      []byte
+	master    *HDKey
+	id        string
+	nextIndex uint32
+}
+
+// seedFile berbagi format enkripsi dengan keystoreFile. NextIndex tidak
+// rahasia sehingga disimpan terbuka dan bisa diperbarui tanpa passphrase.
+type seedFile struct {
+	Version   int            `json:"version"`
+	ID        string         `json:"id"`
+	NextIndex uint32         `json:"next_index"`
+	Crypto    keystoreCrypto `json:"crypto"`
+}
+
+// NewHDWallet membuat HD wallet dari seed. ID-nya adalah receive address
+// index 0, sehingga seed yang sama selalu mendapat ID yang sama.
+func NewHDWallet(seed []byte) (*HDWallet, error) {
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	hw := &HDWallet{seed: seed, master: master}
+	first, err := hw.Receive(0)
+	if err != nil {
+		return nil, err
+	}
+	hw.id = first.BlockchainAddress()
+	return hw, nil
+}
+
+// NewHDWalletFromMnemonic memulihkan HD wallet dari mnemonic BIP-39.
+func NewHDWalletFromMnemonic(mnemonic string, password string) (*HDWallet, error) {
+	seed, err := SeedFromMnemonic(mnemonic, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewHDWallet(seed)
+}
+
+func (hw *HDWallet) ID() string        { return hw.id }
+func (hw *HDWallet) NextIndex() uint32 { return hw.nextIndex }
+
+// Receive menurunkan wallet untuk receive address ke-index.
+func (hw *HDWallet) Receive(index uint32) (*Wallet, error) {
+	key, err := hw.master.Derive(ReceivePath(index))
+	if err != nil {
+		return nil, err
+	}
+	return key.Wallet(), nil
+}
+
+// NextReceive menurunkan receive address berikutnya yang belum dipakai dan
+// memajukan index.
+func (hw *HDWallet) NextReceive() (*Wallet, uint32, error) {
+	index := hw.nextIndex
+	if index >= HD_HARDENED_OFFSET {
+		return nil, 0, ErrInvalidHDPath
+	}
+	w, err := hw.Receive(index)
+	if err != nil {
+		return nil, 0, err
+	}
+	hw.nextIndex++
+	return w, index, nil
+}
+
+// SeedPath adalah nama file seed standar untuk HD wallet dengan id di dir.
+func SeedPath(dir string, id string) string {
+	return filepath.Join(dir, "seed-"+id+".json")
+}
+
+// SaveHDWallet mengenkripsi seed dengan passphrase dan menulisnya ke path.
+func SaveHDWallet(path string, hw *HDWallet, passphrase string) error {
+	c, err := sealKeystore(hw.seed, []byte(hw.id), passphrase)
+	if err != nil {
+		return err
+	}
+	return writeKeystoreFile(path, &seedFile{
+		Version:   KEYSTORE_VERSION,
+		ID:        hw.id,
+		NextIndex: hw.nextIndex,
+		Crypto:    *c,
+	})
+}
+
+func readSeedFile(path string) (*seedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sf seedFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, err
+	}
+	if sf.Version != KEYSTORE_VERSION {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedKeystore, sf.Version)
+	}
+	return &sf, nil
+}
+
+// LoadHDWallet membaca file seed di path dan mendekripsinya dengan passphrase.
+func LoadHDWallet(path string, passphrase string) (*HDWallet, error) {
+	sf, err := readSeedFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := openKeystore(&sf.Crypto, []byte(sf.ID), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	hw, err := NewHDWallet(seed)
+	if err != nil {
+		return nil, err
+	}
+	if hw.ID() != sf.ID {
+		return nil, ErrWrongPassphrase
+	}
+	hw.nextIndex = sf.NextIndex
+	return hw, nil
+}
+
+// SaveHDWalletIndex hanya memperbarui next_index di file seed tanpa
+// mengenkripsi ulang seed.
+func SaveHDWalletIndex(path string, hw *HDWallet) error {
+	sf, err := readSeedFile(path)
+	if err != nil {
+		return err
+	}
+	if sf.ID != hw.ID() {
+		return fmt.Errorf("seed file %s belongs to %s, not %s", path, sf.ID, hw.ID())
+	}
+	sf.NextIndex = hw.nextIndex
+	return writeKeystoreFile(path, sf)
+}
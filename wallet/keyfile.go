@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"learn-blockchain/utils"
+	"os"
+	"path/filepath"
+)
+
+// SaveKeyFile menyimpan wallet (termasuk private key tanpa enkripsi) ke path
+// dengan permission 0600. Dipakai untuk identitas miner yang harus bisa
+// dimuat tanpa interaksi saat node start.
+func SaveKeyFile(path string, w *Wallet) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	m, err := w.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, m, 0o600)
+}
+
+func LoadKeyFile(path string) (*Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v struct {
+		Scheme            string `json:"scheme"`
+		PrivateKey        string `json:"private_key"`
+		PublicKey         string `json:"public_key"`
+		BlockchainAddress string `json:"blockchain_address"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v.PublicKey == "" || v.PrivateKey == "" {
+		return nil, errors.New("key file is missing keys")
+	}
+	scheme, err := utils.ParseScheme(v.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := utils.SignerFromString(scheme, v.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	w := NewWalletFromSigner(signer)
+	if w.PublicKeyStr() != v.PublicKey {
+		return nil, errors.New("key file public key does not match its private key")
+	}
+	if w.BlockchainAddress() != v.BlockchainAddress {
+		return nil, errors.New("key file address does not match its keys")
+	}
+	return w, nil
+}
+
+// LoadOrCreateKeyFile memuat wallet dari path, atau membuat wallet baru dan
+// menyimpannya bila file belum ada.
+func LoadOrCreateKeyFile(path string) (*Wallet, error) {
+	w, err := LoadKeyFile(path)
+	if err == nil {
+		return w, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	w = NewWallet()
+	if err := SaveKeyFile(path, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
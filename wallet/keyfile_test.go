@@ -0,0 +1,102 @@
+package wallet
+
+import (
+	"encoding/json"
+	"learn-blockchain/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyFileRoundTrip(t *testing.T) {
+	for _, scheme := range []utils.Scheme{utils.SCHEME_P256, utils.SCHEME_SECP256K1, utils.SCHEME_ED25519} {
+		t.Run(string(scheme), func(t *testing.T) {
+			w, err := NewWalletWithScheme(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "node", "miner.key")
+			if err := SaveKeyFile(path, w); err != nil {
+				t.Fatalf("SaveKeyFile: %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0o600 {
+				t.Errorf("key file permission = %o, want 600", perm)
+			}
+
+			loaded, err := LoadKeyFile(path)
+			if err != nil {
+				t.Fatalf("LoadKeyFile: %v", err)
+			}
+			if loaded.Scheme() != scheme || loaded.BlockchainAddress() != w.BlockchainAddress() {
+				t.Errorf("loaded %s wallet %s, want %s wallet %s",
+					loaded.Scheme(), loaded.BlockchainAddress(), scheme, w.BlockchainAddress())
+			}
+		})
+	}
+}
+
+func TestLoadKeyFileInvalid(t *testing.T) {
+	w := NewWallet()
+	other := NewWallet()
+	valid := map[string]string{
+		"scheme":             string(w.Scheme()),
+		"private_key":        w.PrivateKeyStr(),
+		"public_key":         w.PublicKeyStr(),
+		"blockchain_address": w.BlockchainAddress(),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(v map[string]string)
+		raw    string
+	}{
+		{name: "not json", raw: "{"},
+		{name: "missing private key", modify: func(v map[string]string) { delete(v, "private_key") }},
+		{name: "unknown scheme", modify: func(v map[string]string) { v["scheme"] = "rsa" }},
+		{name: "bad private key", modify: func(v map[string]string) { v["private_key"] = "zz" }},
+		{name: "public key mismatch", modify: func(v map[string]string) { v["public_key"] = other.PublicKeyStr() }},
+		{name: "address mismatch", modify: func(v map[string]string) { v["blockchain_address"] = other.BlockchainAddress() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.raw)
+			if tt.modify != nil {
+				v := map[string]string{}
+				for k, s := range valid {
+					v[k] = s
+				}
+				tt.modify(v)
+				data, _ = json.Marshal(v)
+			}
+			path := filepath.Join(t.TempDir(), "miner.key")
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadKeyFile(path); err == nil {
+				t.Error("LoadKeyFile succeeded, want error")
+			}
+			if _, err := LoadOrCreateKeyFile(path); err == nil {
+				t.Error("LoadOrCreateKeyFile replaced an invalid key file, want error")
+			}
+		})
+	}
+}
+
+func TestLoadOrCreateKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miner.key")
+	created, err := LoadOrCreateKeyFile(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	loaded, err := LoadOrCreateKeyFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.BlockchainAddress() != created.BlockchainAddress() {
+		t.Errorf("second start loaded %s, want the created key %s", loaded.BlockchainAddress(), created.BlockchainAddress())
+	}
+}
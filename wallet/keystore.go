@@ -0,0 +1,207 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn-blockchain/utils"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Format keystore: private key dienkripsi AES-256-GCM dengan key yang
+// diturunkan dari passphrase memakai scrypt (memory-hard). Address ikut
+// menjadi additional data sehingga ciphertext tidak bisa dipindah ke file lain.
+const (
+	KEYSTORE_VERSION = 1
+	KEYSTORE_CIPHER  = "aes-256-gcm"
+	KEYSTORE_KDF     = "scrypt"
+
+	keystoreScryptN     = 1 << 15
+	keystoreScryptR     = 8
+	keystoreScryptP     = 1
+	keystoreScryptDKLen = 32
+	keystoreSaltSize    = 32
+)
+
+var (
+	ErrWrongPassphrase     = errors.New("wrong passphrase or corrupted keystore")
+	ErrUnsupportedKeystore = errors.New("unsupported keystore format")
+)
+
+type keystoreKDFParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+type keystoreCrypto struct {
+	Cipher     string            `json:"cipher"`
+	Ciphertext string            `json:"ciphertext"`
+	Nonce      string            `json:"nonce"`
+	KDF        string            `json:"kdf"`
+	KDFParams  keystoreKDFParams `json:"kdfparams"`
+}
+
+// Scheme kosong pada file lama berarti P-256.
+type keystoreFile struct {
+	Version           int            `json:"version"`
+	Scheme            utils.Scheme   `json:"scheme,omitempty"`
+	BlockchainAddress string         `json:"blockchain_address"`
+	PublicKey         string         `json:"public_key"`
+	Crypto            keystoreCrypto `json:"crypto"`
+}
+
+// KeystorePath adalah nama file keystore standar untuk address di dir.
+func KeystorePath(dir string, blockchainAddress string) string {
+	return filepath.Join(dir, blockchainAddress+".json")
+}
+
+// keystoreAEAD hanya menerima parameter scrypt yang ditulis sealKeystore,
+// supaya file keystore tidak bisa memaksa alokasi memori atau CPU yang besar.
+func keystoreAEAD(passphrase string, params keystoreKDFParams) (cipher.AEAD, error) {
+	if params.N != keystoreScryptN || params.R != keystoreScryptR ||
+		params.P != keystoreScryptP || params.DKLen != keystoreScryptDKLen {
+		return nil, ErrUnsupportedKeystore
+	}
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil || len(salt) != keystoreSaltSize {
+		return nil, ErrUnsupportedKeystore
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealKeystore mengenkripsi secret dengan key turunan passphrase; aad ikut
+// diautentikasi tetapi tidak dienkripsi.
+func sealKeystore(secret []byte, aad []byte, passphrase string) (*keystoreCrypto, error) {
+	salt := make([]byte, keystoreSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := keystoreKDFParams{
+		N:     keystoreScryptN,
+		R:     keystoreScryptR,
+		P:     keystoreScryptP,
+		DKLen: keystoreScryptDKLen,
+		Salt:  hex.EncodeToString(salt),
+	}
+	aead, err := keystoreAEAD(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, secret, aad)
+	return &keystoreCrypto{
+		Cipher:     KEYSTORE_CIPHER,
+		Ciphertext: hex.EncodeToString(ciphertext),
+		Nonce:      hex.EncodeToString(nonce),
+		KDF:        KEYSTORE_KDF,
+		KDFParams:  params,
+	}, nil
+}
+
+// openKeystore adalah kebalikan sealKeystore. Passphrase yang salah atau aad
+// yang berbeda menghasilkan ErrWrongPassphrase.
+func openKeystore(c *keystoreCrypto, aad []byte, passphrase string) ([]byte, error) {
+	if c.Cipher != KEYSTORE_CIPHER || c.KDF != KEYSTORE_KDF {
+		return nil, ErrUnsupportedKeystore
+	}
+	aead, err := keystoreAEAD(passphrase, c.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	nonce, errNonce := hex.DecodeString(c.Nonce)
+	ciphertext, errCiphertext := hex.DecodeString(c.Ciphertext)
+	if errNonce != nil || errCiphertext != nil || len(nonce) != aead.NonceSize() {
+		return nil, ErrUnsupportedKeystore
+	}
+	secret, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return secret, nil
+}
+
+// writeKeystoreFile menulis v sebagai JSON ke path dengan permission 0600.
+func writeKeystoreFile(path string, v interface{}) error {
+	m, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, m, 0o600)
+}
+
+// Save mengenkripsi private key wallet dengan passphrase dan menulisnya ke
+// path dengan permission 0600.
+func Save(path string, w *Wallet, passphrase string) error {
+	c, err := sealKeystore(w.signer.Bytes(), []byte(w.BlockchainAddress()), passphrase)
+	if err != nil {
+		return err
+	}
+	return writeKeystoreFile(path, &keystoreFile{
+		Version:           KEYSTORE_VERSION,
+		Scheme:            w.Scheme(),
+		BlockchainAddress: w.BlockchainAddress(),
+		PublicKey:         w.PublicKeyStr(),
+		Crypto:            *c,
+	})
+}
+
+// Load membaca keystore di path dan mendekripsi private key-nya dengan
+// passphrase. Passphrase yang salah menghasilkan ErrWrongPassphrase.
+func Load(path string, passphrase string) (*Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ks keystoreFile
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, err
+	}
+	if ks.Version != KEYSTORE_VERSION {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedKeystore, ks.Version)
+	}
+	scheme, err := utils.ParseScheme(string(ks.Scheme))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKeystore, err)
+	}
+
+	privateKey, err := openKeystore(&ks.Crypto, []byte(ks.BlockchainAddress), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := utils.SignerFromBytes(scheme, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	// Address sudah diautentikasi sebagai additional data, jadi cukup
+	// dibandingkan dengan address dari key hasil dekripsi.
+	w := NewWalletFromSigner(signer)
+	if w.BlockchainAddress() != ks.BlockchainAddress || w.PublicKeyStr() != ks.PublicKey {
+		return nil, ErrWrongPassphrase
+	}
+	return w, nil
+}
@@ -0,0 +1,132 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"learn-blockchain/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	for _, scheme := range []utils.Scheme{utils.SCHEME_P256, utils.SCHEME_SECP256K1, utils.SCHEME_ED25519} {
+		t.Run(string(scheme), func(t *testing.T) {
+			w, err := NewWalletWithScheme(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := KeystorePath(t.TempDir(), w.BlockchainAddress())
+			if err := Save(path, w, "correct horse"); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0o600 {
+				t.Errorf("keystore permission = %o, want 600", perm)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), w.PrivateKeyStr()) {
+				t.Error("keystore contains the private key in plain text")
+			}
+
+			loaded, err := Load(path, "correct horse")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if loaded.Scheme() != scheme || loaded.PrivateKeyStr() != w.PrivateKeyStr() ||
+				loaded.BlockchainAddress() != w.BlockchainAddress() {
+				t.Error("loaded wallet does not match the saved wallet")
+			}
+
+			if _, err := Load(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+				t.Errorf("Load with wrong passphrase error = %v, want %v", err, ErrWrongPassphrase)
+			}
+		})
+	}
+}
+
+func TestKeystoreTampered(t *testing.T) {
+	w := NewWallet()
+	other := NewWallet()
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	if err := Save(path, w, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(ks *keystoreFile)
+		want   error
+	}{
+		{"address moved to other wallet", func(ks *keystoreFile) {
+			ks.BlockchainAddress = other.BlockchainAddress()
+		}, ErrWrongPassphrase},
+		{"public key replaced", func(ks *keystoreFile) {
+			ks.PublicKey = other.PublicKeyStr()
+		}, ErrWrongPassphrase},
+		{"ciphertext flipped", func(ks *keystoreFile) {
+			c := []byte(ks.Crypto.Ciphertext)
+			if c[0] == '0' {
+				c[0] = '1'
+			} else {
+				c[0] = '0'
+			}
+			ks.Crypto.Ciphertext = string(c)
+		}, ErrWrongPassphrase},
+		{"unknown version", func(ks *keystoreFile) {
+			ks.Version = KEYSTORE_VERSION + 1
+		}, ErrUnsupportedKeystore},
+		{"unknown cipher", func(ks *keystoreFile) {
+			ks.Crypto.Cipher = "aes-128-ctr"
+		}, ErrUnsupportedKeystore},
+		{"unknown scheme", func(ks *keystoreFile) {
+			ks.Scheme = "rsa"
+		}, ErrUnsupportedKeystore},
+		{"huge scrypt n", func(ks *keystoreFile) {
+			ks.Crypto.KDFParams.N = 1 << 40
+		}, ErrUnsupportedKeystore},
+		{"huge scrypt r", func(ks *keystoreFile) {
+			ks.Crypto.KDFParams.R = 1 << 20
+		}, ErrUnsupportedKeystore},
+		{"huge scrypt p", func(ks *keystoreFile) {
+			ks.Crypto.KDFParams.P = 1 << 20
+		}, ErrUnsupportedKeystore},
+		{"weak scrypt n", func(ks *keystoreFile) {
+			ks.Crypto.KDFParams.N = 2
+		}, ErrUnsupportedKeystore},
+		{"short derived key", func(ks *keystoreFile) {
+			ks.Crypto.KDFParams.DKLen = 16
+		}, ErrUnsupportedKeystore},
+		{"short salt", func(ks *keystoreFile) {
+			ks.Crypto.KDFParams.Salt = "00"
+		}, ErrUnsupportedKeystore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ks keystoreFile
+			if err := json.Unmarshal(data, &ks); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(&ks)
+			tampered := filepath.Join(t.TempDir(), "tampered.json")
+			if err := writeKeystoreFile(tampered, &ks); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(tampered, "passphrase"); !errors.Is(err, tt.want) {
+				t.Errorf("Load error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
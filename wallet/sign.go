@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"learn-blockchain/block"
+	"learn-blockchain/utils"
+)
+
+var ErrSigningHashMismatch = errors.New("signing hash does not match transaction")
+
+// PreparedTransaction adalah transaksi yang belum ditandatangani beserta
+// payload kanonik dan hash-nya. Client menandatangani hash dengan scheme
+// key-nya (untuk ECDSA P-256 sama dengan menandatangani payload dengan
+// SHA-256) lalu mengisi Transaction.Signature sebelum submit.
+type PreparedTransaction struct {
+	Transaction    *block.TransactionRequest `json:"transaction"`
+	SigningPayload string                    `json:"signing_payload"`
+	SigningHash    string                    `json:"signing_hash"`
+}
+
+func NewPreparedTransaction(senderPublicKey utils.Verifier, sender string, recipient string, value block.Amount, fee block.Amount, nonce uint64) *PreparedTransaction {
+	bt := block.NewUnsignedTransaction(senderPublicKey, sender, recipient, value, fee, nonce)
+	h := bt.SigningHash()
+	schemeStr := string(senderPublicKey.Scheme())
+	publicKey := utils.PublicKeyHex(senderPublicKey)
+	return &PreparedTransaction{
+		Transaction: &block.TransactionRequest{
+			SenderBlockchainAddress:    &sender,
+			RecipientBlockchainAddress: &recipient,
+			Scheme:                     &schemeStr,
+			SenderPublicKey:            &publicKey,
+			Value:                      &value,
+			Fee:                        &fee,
+			Nonce:                      &nonce,
+		},
+		SigningPayload: hex.EncodeToString(bt.SigningBytes()),
+		SigningHash:    hex.EncodeToString(h[:]),
+	}
+}
+
+// Sign menandatangani transaksi secara lokal. Hash dihitung ulang dari isi
+// transaksi, jadi server yang mengirim hash palsu tidak bisa membuat client
+// menandatangani transaksi lain.
+func (p *PreparedTransaction) Sign(signer utils.Signer) error {
+	t := p.Transaction
+	if t == nil || t.SenderBlockchainAddress == nil || t.RecipientBlockchainAddress == nil ||
+		t.Value == nil || t.Nonce == nil {
+		return errors.New("prepared transaction is missing fields")
+	}
+	if addr := utils.BlockchainAddress(signer.Public()); addr != *t.SenderBlockchainAddress {
+		return fmt.Errorf("private key belongs to %s, not %s", addr, *t.SenderBlockchainAddress)
+	}
+
+	bt := block.NewUnsignedTransaction(signer.Public(), *t.SenderBlockchainAddress, *t.RecipientBlockchainAddress,
+		*t.Value, t.FeeAmount(), *t.Nonce)
+	h := bt.SigningHash()
+	if p.SigningHash != "" && p.SigningHash != hex.EncodeToString(h[:]) {
+		return ErrSigningHashMismatch
+	}
+
+	// Signature P-256 dan secp256k1 deterministik (RFC 6979) dan low-S,
+	// Ed25519 deterministik menurut definisinya.
+	signature, err := signer.Sign(h[:])
+	if err != nil {
+		return err
+	}
+	scheme := string(signer.Scheme())
+	publicKeyStr := utils.PublicKeyHex(signer.Public())
+	signatureStr := hex.EncodeToString(signature)
+	t.Scheme = &scheme
+	t.SenderPublicKey = &publicKeyStr
+	t.Signature = &signatureStr
+	return nil
+}
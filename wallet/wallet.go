@@ -4,74 +4,64 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"learn-blockchain/block"
 	"learn-blockchain/utils"
-
-	"github.com/btcsuite/btcutil/base58"
-	"golang.org/x/crypto/ripemd160"
 )
 
 type Wallet struct {
-	privateKey       *ecdsa.PrivateKey
-	publicKey        *ecdsa.PublicKey
+	signer           utils.Signer
+	publicKey        utils.Verifier
 	blockchainAddres string
 }
 
+// NewWallet membuat wallet P-256 acak.
 func NewWallet() *Wallet {
-	w := new(Wallet)
 	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	w.privateKey = privateKey
-	w.publicKey = &w.privateKey.PublicKey
-
-	h2 := sha256.New()
-	h2.Write(w.publicKey.X.Bytes())
-	h2.Write(w.publicKey.Y.Bytes())
-	digest2 := h2.Sum(nil)
-
-	h3 := ripemd160.New()
-	h3.Write(digest2)
-	digest3 := h3.Sum(nil)
-
-	vd4 := make([]byte, 21)
-	vd4[0] = 0x00
-	copy(vd4[1:], digest3[:])
-
-	h5 := sha256.New()
-	h5.Write(vd4)
-	digest5 := h5.Sum(nil)
-
-	h6 := sha256.New()
-	h6.Write(digest5)
-	digest6 := h6.Sum(nil)
+	return NewWalletFromPrivateKey(privateKey)
+}
 
-	chsum := digest6[:4]
+// NewWalletWithScheme membuat wallet acak untuk scheme signature tertentu.
+func NewWalletWithScheme(scheme utils.Scheme) (*Wallet, error) {
+	signer, err := utils.GenerateSigner(scheme)
+	if err != nil {
+		return nil, err
+	}
+	return NewWalletFromSigner(signer), nil
+}
 
-	dc8 := make([]byte, 25)
-	copy(dc8[:21], vd4[:])
-	copy(dc8[21:], chsum[:])
+func NewWalletFromPrivateKey(privateKey *ecdsa.PrivateKey) *Wallet {
+	return NewWalletFromSigner(utils.NewP256Signer(privateKey))
+}
 
-	address := base58.Encode(dc8)
-	w.blockchainAddres = address
+func NewWalletFromSigner(signer utils.Signer) *Wallet {
+	w := new(Wallet)
+	w.signer = signer
+	w.publicKey = signer.Public()
+	w.blockchainAddres = utils.BlockchainAddress(w.publicKey)
 
 	return w
 }
 
-func (w *Wallet) PrivateKey() *ecdsa.PrivateKey {
-	return w.privateKey
+func (w *Wallet) Signer() utils.Signer {
+	return w.signer
+}
+
+func (w *Wallet) Scheme() utils.Scheme {
+	return w.signer.Scheme()
 }
 
 func (w *Wallet) PrivateKeyStr() string {
-	return fmt.Sprintf("%x", w.privateKey.D.Bytes())
+	return hex.EncodeToString(w.signer.Bytes())
 }
 
-func (w *Wallet) PublicKey() *ecdsa.PublicKey {
+func (w *Wallet) PublicKey() utils.Verifier {
 	return w.publicKey
 }
 
 func (w *Wallet) PublicKeyStr() string {
-	return fmt.Sprintf("%064x%064x", w.publicKey.X.Bytes(), w.publicKey.Y.Bytes())
+	return utils.PublicKeyHex(w.publicKey)
 }
 
 func (w *Wallet) BlockchainAddress() string {
@@ -80,59 +70,97 @@ func (w *Wallet) BlockchainAddress() string {
 
 func (w *Wallet) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		PrivateKey        string `json:"private_key"`
-		PublicKey         string `json:"public_key"`
-		BlockchainAddress string `json:"blockchain_address"`
+		Scheme            utils.Scheme `json:"scheme"`
+		PrivateKey        string       `json:"private_key"`
+		PublicKey         string       `json:"public_key"`
+		BlockchainAddress string       `json:"blockchain_address"`
 	}{
+		Scheme:            w.Scheme(),
 		PrivateKey:        w.PrivateKeyStr(),
 		PublicKey:         w.PublicKeyStr(),
 		BlockchainAddress: w.BlockchainAddress(),
 	})
 }
 
+// MarshalPublicJSON sama dengan MarshalJSON tetapi tanpa private key, untuk
+// dikirim ke browser setelah wallet disimpan di keystore.
+func (w *Wallet) MarshalPublicJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Scheme            utils.Scheme `json:"scheme"`
+		PublicKey         string       `json:"public_key"`
+		BlockchainAddress string       `json:"blockchain_address"`
+	}{
+		Scheme:            w.Scheme(),
+		PublicKey:         w.PublicKeyStr(),
+		BlockchainAddress: w.BlockchainAddress(),
+	})
+}
+
 type Transaction struct {
-	senderPrivateKey          *ecdsa.PrivateKey
-	senderPublicKey           *ecdsa.PublicKey
+	signer                    utils.Signer
 	senderBlockchainAddress   string
 	recipentBlockchainAddress string
-	value                     float32
+	value                     block.Amount
+	fee                       block.Amount
+	nonce                     uint64
 }
 
-func NewTransaction(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey, sender string, recipent string, value float32) *Transaction {
-	return &Transaction{privateKey, publicKey, sender, recipent, value}
+func NewTransaction(signer utils.Signer, sender string, recipent string, value block.Amount, fee block.Amount, nonce uint64) *Transaction {
+	return &Transaction{signer, sender, recipent, value, fee, nonce}
 }
 
-func (t *Transaction) GenerateSignature() *utils.Signature {
-	m, _ := json.Marshal(t)
-	h := sha256.Sum256([]byte(m))
-	r, s, _ := ecdsa.Sign(rand.Reader, t.senderPrivateKey, h[:])
-
-	return &utils.Signature{r, s}
+// GenerateSignature menandatangani encoding biner kanonik transaksi, sama
+// dengan yang diverifikasi oleh block.Blockchain.VerifyTransaction.
+func (t *Transaction) GenerateSignature() ([]byte, error) {
+	bt := block.NewUnsignedTransaction(t.signer.Public(), t.senderBlockchainAddress, t.recipentBlockchainAddress,
+		t.value, t.fee, t.nonce)
+	h := bt.SigningHash()
+	return t.signer.Sign(h[:])
 }
 
 func (t *Transaction) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Sender    string  `json:"sender_blockchain_address"`
-		Recipient string  `json:"recipient_blockchain_address"`
-		Value     float32 `json:"value"`
+		Sender    string       `json:"sender_blockchain_address"`
+		Recipient string       `json:"recipient_blockchain_address"`
+		Value     block.Amount `json:"value"`
+		Fee       block.Amount `json:"fee"`
+		Nonce     uint64       `json:"nonce"`
 	}{
 		Sender:    t.senderBlockchainAddress,
 		Recipient: t.recipentBlockchainAddress,
 		Value:     t.value,
+		Fee:       t.fee,
+		Nonce:     t.nonce,
 	})
 }
 
+// TransactionRequest tidak membawa private key: transaksi ditandatangani oleh
+// client (lihat PreparedTransaction) atau oleh wallet server, yang memerlukan
+// SessionToken dari unlock atau Passphrase keystore sender. Fee boleh kosong
+// untuk fee nol. Nonce hanya diisi untuk mengganti transaksi pending dengan
+// fee lebih tinggi; bila kosong dipakai nonce berikutnya dari gateway.
 type TransactionRequest struct {
-	SenderPrivateKey           *string `json:"sender_private_key"`
+	Scheme                     *string `json:"scheme,omitempty"`
 	SenderBlockchainAddress    *string `json:"sender_blockchain_address"`
 	RecipientBlockchainAddress *string `json:"recipient_blockchain_address"`
 	SenderPublicKey            *string `json:"sender_public_key"`
 	Value                      *string `json:"value"`
+	Fee                        *string `json:"fee,omitempty"`
+	Nonce                      *uint64 `json:"nonce,omitempty"`
+	SessionToken               *string `json:"session_token,omitempty"`
+	Passphrase                 *string `json:"passphrase,omitempty"`
+}
+
+// ParseFee mengurai Fee, atau nol bila tidak diisi.
+func (tr *TransactionRequest) ParseFee() (block.Amount, error) {
+	if tr.Fee == nil || *tr.Fee == "" {
+		return 0, nil
+	}
+	return block.ParseAmount(*tr.Fee)
 }
 
 func (tr *TransactionRequest) Validate() bool {
-	if tr.SenderPrivateKey == nil ||
-		tr.SenderBlockchainAddress == nil ||
+	if tr.SenderBlockchainAddress == nil ||
 		tr.RecipientBlockchainAddress == nil ||
 		tr.SenderPublicKey == nil ||
 		tr.Value == nil {
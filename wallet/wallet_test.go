@@ -0,0 +1,39 @@
+package wallet
+
+import (
+	"errors"
+	"learn-blockchain/block"
+	"learn-blockchain/utils"
+	"testing"
+)
+
+// failingSigner memakai public key asli tetapi selalu gagal menandatangani.
+type failingSigner struct{ utils.Signer }
+
+var errTestSign = errors.New("signer unavailable")
+
+func (failingSigner) Sign(hash []byte) ([]byte, error) { return nil, errTestSign }
+
+func TestGenerateSignature(t *testing.T) {
+	for _, scheme := range []utils.Scheme{utils.SCHEME_P256, utils.SCHEME_SECP256K1, utils.SCHEME_ED25519} {
+		t.Run(string(scheme), func(t *testing.T) {
+			w, err := NewWalletWithScheme(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signature, err := NewTransaction(w.Signer(), w.BlockchainAddress(), "recipient", 1, 0, 0).GenerateSignature()
+			if err != nil {
+				t.Fatalf("GenerateSignature: %v", err)
+			}
+			h := block.NewUnsignedTransaction(w.PublicKey(), w.BlockchainAddress(), "recipient", 1, 0, 0).SigningHash()
+			if err := w.PublicKey().Verify(h[:], signature); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+
+			failing := failingSigner{w.Signer()}
+			if _, err := NewTransaction(failing, w.BlockchainAddress(), "recipient", 1, 0, 0).GenerateSignature(); !errors.Is(err, errTestSign) {
+				t.Errorf("GenerateSignature error = %v, want %v", err, errTestSign)
+			}
+		})
+	}
+}
@@ -12,8 +12,9 @@ func init() {
 func main() {
 	port := flag.Uint("port", 8080, "TCP port number for wallet server")
 	gateway := flag.String("gateway", "http://127.0.0.1:5000", "Blockchain Gateway")
+	keystoreDir := flag.String("keystore", "keystore", "Directory for encrypted wallet keystore files")
 	flag.Parse()
 
-	app := NewWalletServer(uint16(*port), *gateway)
+	app := NewWalletServer(uint16(*port), *gateway, *keystoreDir)
 	app.Run()
 }
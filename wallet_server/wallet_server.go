@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"learn-blockchain/block"
@@ -10,24 +13,137 @@ import (
 	"learn-blockchain/wallet"
 	"log"
 	"net/http"
+	"os"
 	"path"
+	"path/filepath"
 	"strconv"
+	"sync"
 	"text/template"
+	"time"
 )
 
 const tempDir = "wallet_server/templates"
 
+// UNLOCK_SESSION_TTL adalah lama token sesi dari unlock berlaku. Setelah itu
+// key dihapus dari memori dan wallet harus di-unlock ulang.
+const UNLOCK_SESSION_TTL = 5 * time.Minute
+
+var errWalletLocked = errors.New("wallet is locked: session_token or passphrase required")
+
+// unlockSession memegang wallet yang sudah di-unlock untuk satu token.
+type unlockSession struct {
+	wallet  *wallet.Wallet
+	expires time.Time
+}
+
 type WalletServer struct {
-	port    uint16
-	gateway string
+	port        uint16
+	gateway     string
+	keystoreDir string
+
+	// Wallet yang sudah di-unlock, per token sesi yang dikembalikan ke client
+	sessions    map[string]*unlockSession
+	sessionTTL  time.Duration
+	muxSessions sync.Mutex
+
+	// Menjaga next_index di file seed saat derive berjalan bersamaan
+	muxSeeds sync.Mutex
 }
 
-func NewWalletServer(port uint16, gateway string) *WalletServer {
-	return &WalletServer{port: port, gateway: gateway}
+func NewWalletServer(port uint16, gateway string, keystoreDir string) *WalletServer {
+	return &WalletServer{
+		port:        port,
+		gateway:     gateway,
+		keystoreDir: keystoreDir,
+		sessions:    make(map[string]*unlockSession),
+		sessionTTL:  UNLOCK_SESSION_TTL,
+	}
 }
 
-func (ws *WalletServer) Port() uint16    { return ws.port }
-func (ws *WalletServer) Gateway() string { return ws.gateway }
+func (ws *WalletServer) Port() uint16        { return ws.port }
+func (ws *WalletServer) Gateway() string     { return ws.gateway }
+func (ws *WalletServer) KeystoreDir() string { return ws.keystoreDir }
+
+// unlock menyimpan wallet di memori dan mengembalikan token sesi acak yang
+// berlaku selama sessionTTL. Sesi yang sudah kedaluwarsa ikut dibuang.
+func (ws *WalletServer) unlock(myWallet *wallet.Wallet) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	ws.muxSessions.Lock()
+	defer ws.muxSessions.Unlock()
+	now := time.Now()
+	for t, session := range ws.sessions {
+		if !now.Before(session.expires) {
+			delete(ws.sessions, t)
+		}
+	}
+	ws.sessions[token] = &unlockSession{wallet: myWallet, expires: now.Add(ws.sessionTTL)}
+	return token, nil
+}
+
+// sessionWallet mengembalikan wallet untuk token yang belum kedaluwarsa dan
+// milik blockchainAddress.
+func (ws *WalletServer) sessionWallet(token string, blockchainAddress string) (*wallet.Wallet, bool) {
+	ws.muxSessions.Lock()
+	defer ws.muxSessions.Unlock()
+	session, ok := ws.sessions[token]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(session.expires) {
+		delete(ws.sessions, token)
+		return nil, false
+	}
+	if session.wallet.BlockchainAddress() != blockchainAddress {
+		return nil, false
+	}
+	return session.wallet, true
+}
+
+// signingWallet memilih wallet penanda tangan untuk /transaction: dari token
+// sesi, atau dari keystore dengan passphrase pada request itu sendiri.
+func (ws *WalletServer) signingWallet(t *wallet.TransactionRequest) (*wallet.Wallet, error) {
+	sender := *t.SenderBlockchainAddress
+	switch {
+	case t.SessionToken != nil:
+		myWallet, ok := ws.sessionWallet(*t.SessionToken, sender)
+		if !ok {
+			return nil, errors.New("invalid or expired session token")
+		}
+		return myWallet, nil
+	case t.Passphrase != nil:
+		if !validKeystoreName(sender) {
+			return nil, errors.New("invalid sender address")
+		}
+		return wallet.Load(wallet.KeystorePath(ws.KeystoreDir(), sender), *t.Passphrase)
+	default:
+		return nil, errWalletLocked
+	}
+}
+
+// unlockResponse berisi data publik wallet dan token sesi untuk /transaction.
+type unlockResponse struct {
+	Scheme            utils.Scheme `json:"scheme"`
+	PublicKey         string       `json:"public_key"`
+	BlockchainAddress string       `json:"blockchain_address"`
+	SessionToken      string       `json:"session_token"`
+	// ExpiresIn dalam detik
+	ExpiresIn int `json:"expires_in"`
+}
+
+func (ws *WalletServer) newUnlockResponse(myWallet *wallet.Wallet, token string) *unlockResponse {
+	return &unlockResponse{
+		Scheme:            myWallet.Scheme(),
+		PublicKey:         myWallet.PublicKeyStr(),
+		BlockchainAddress: myWallet.BlockchainAddress(),
+		SessionToken:      token,
+		ExpiresIn:         int(ws.sessionTTL / time.Second),
+	}
+}
 
 /*
 *
@@ -46,12 +162,231 @@ func (ws *WalletServer) Index(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Wallet membuat wallet baru dengan scheme opsional (default P-256). Wallet
+// selalu disimpan terenkripsi dengan passphrase di keystore dan langsung
+// di-unlock; private key tidak pernah dikirim ke browser.
 func (ws *WalletServer) Wallet(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
+		var wr struct {
+			Scheme     string  `json:"scheme"`
+			Passphrase *string `json:"passphrase"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&wr); err != nil ||
+			wr.Passphrase == nil || *wr.Passphrase == "" {
+			log.Println("ERROR: missing field(s)")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		scheme, err := utils.ParseScheme(wr.Scheme)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+		myWallet, err := wallet.NewWalletWithScheme(scheme)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		path := wallet.KeystorePath(ws.KeystoreDir(), myWallet.BlockchainAddress())
+		if err := wallet.Save(path, myWallet, *wr.Passphrase); err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		log.Printf("Saved wallet %s to %s", myWallet.BlockchainAddress(), path)
+		ws.writeUnlocked(w, myWallet)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ERROR: HTTP Method")
+	}
+}
+
+func (ws *WalletServer) WalletUnlock(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var ur struct {
+			BlockchainAddress *string `json:"blockchain_address"`
+			Passphrase        *string `json:"passphrase"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&ur); err != nil ||
+			ur.BlockchainAddress == nil || ur.Passphrase == nil {
+			log.Println("ERROR: missing field(s)")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		if !validKeystoreName(*ur.BlockchainAddress) {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		myWallet, err := wallet.Load(wallet.KeystorePath(ws.KeystoreDir(), *ur.BlockchainAddress), *ur.Passphrase)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+		ws.writeUnlocked(w, myWallet)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ERROR: HTTP Method")
+	}
+}
+
+// writeUnlocked membuka sesi untuk wallet dan menulis unlockResponse.
+func (ws *WalletServer) writeUnlocked(w http.ResponseWriter, myWallet *wallet.Wallet) {
+	token, err := ws.unlock(myWallet)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, string(utils.JsonStatus("fail")))
+		return
+	}
+	m, _ := json.Marshal(ws.newUnlockResponse(myWallet, token))
+	io.WriteString(w, string(m[:]))
+}
+
+// validKeystoreName memastikan nama yang dipakai sebagai nama file di
+// keystore tidak berisi path.
+func validKeystoreName(name string) bool {
+	return name != "" && filepath.Base(name) == name
+}
+
+// HDWallet membuat HD wallet baru, atau memulihkannya bila body berisi
+// mnemonic, lalu menyimpan seed terenkripsi di keystore. Mnemonic hanya
+// dikembalikan sekali saat dibuat dan harus dicatat user sebagai backup.
+func (ws *WalletServer) HDWallet(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var hr struct {
+			Passphrase *string `json:"passphrase"`
+			Mnemonic   *string `json:"mnemonic"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&hr); err != nil || hr.Passphrase == nil {
+			log.Println("ERROR: missing field(s)")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		var mnemonic string
+		var err error
+		if hr.Mnemonic != nil {
+			mnemonic = *hr.Mnemonic
+		} else if mnemonic, err = wallet.NewMnemonic(); err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		hw, err := wallet.NewHDWalletFromMnemonic(mnemonic, "")
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+
+		ws.muxSeeds.Lock()
+		defer ws.muxSeeds.Unlock()
+		path := wallet.SeedPath(ws.KeystoreDir(), hw.ID())
+		if _, err := os.Stat(path); err == nil {
+			// Seed sudah tersimpan: pertahankan next_index yang lama
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, string(utils.JsonError(fmt.Errorf("hd wallet %s already exists", hw.ID()))))
+			return
+		}
+		if err := wallet.SaveHDWallet(path, hw, *hr.Passphrase); err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		log.Printf("Saved hd wallet %s to %s", hw.ID(), path)
+
+		resp := struct {
+			WalletID string `json:"wallet_id"`
+			Mnemonic string `json:"mnemonic,omitempty"`
+		}{WalletID: hw.ID()}
+		if hr.Mnemonic == nil {
+			resp.Mnemonic = mnemonic
+		}
+		m, _ := json.Marshal(resp)
+		io.WriteString(w, string(m[:]))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ERROR: HTTP Method")
+	}
+}
+
+// HDReceive menurunkan receive address berikutnya dari seed yang tersimpan.
+// Wallet hasil derive di-unlock dengan token sesi yang sama seperti
+// /wallet/unlock, sehingga bisa dipakai di /transaction sampai kedaluwarsa.
+func (ws *WalletServer) HDReceive(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var hr struct {
+			WalletID   *string `json:"wallet_id"`
+			Passphrase *string `json:"passphrase"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&hr); err != nil ||
+			hr.WalletID == nil || hr.Passphrase == nil || !validKeystoreName(*hr.WalletID) {
+			log.Println("ERROR: missing field(s)")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
 		w.Header().Add("Content-Type", "application/json")
-		myWallet := wallet.NewWallet()
-		m, _ := myWallet.MarshalJSON()
+		ws.muxSeeds.Lock()
+		defer ws.muxSeeds.Unlock()
+		path := wallet.SeedPath(ws.KeystoreDir(), *hr.WalletID)
+		hw, err := wallet.LoadHDWallet(path, *hr.Passphrase)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+		myWallet, index, err := hw.NextReceive()
+		if err == nil {
+			err = wallet.SaveHDWalletIndex(path, hw)
+		}
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		token, err := ws.unlock(myWallet)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		m, _ := json.Marshal(struct {
+			*unlockResponse
+			Path  string `json:"path"`
+			Index uint32 `json:"index"`
+		}{
+			unlockResponse: ws.newUnlockResponse(myWallet, token),
+			Path:           wallet.ReceivePath(index),
+			Index:          index,
+		})
 		io.WriteString(w, string(m[:]))
 	default:
 		w.WriteHeader(http.StatusBadRequest)
@@ -74,53 +409,209 @@ func (ws *WalletServer) CreateTransaction(w http.ResponseWriter, req *http.Reque
 
 		if !t.Validate() {
 			log.Println("ERROR : Missing fields!")
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
 		}
 
-		publicKey := utils.PublicKeyFromString(*t.SenderPublicKey)
-		privateKey := utils.PrivateKeyFromString(*t.SenderPrivateKey, publicKey)
-
-		value, err := strconv.ParseFloat(*t.Value, 32)
+		// Server hanya menandatangani dengan token sesi atau passphrase yang
+		// valid; wallet lain memakai /transaction/prepare dan /transaction/submit.
+		myWallet, err := ws.signingWallet(&t)
 		if err != nil {
-			log.Println("ERROR : parse")
-			io.WriteString(w, string(utils.JsonStatus("fail")))
+			log.Printf("ERROR : %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, string(utils.JsonError(err)))
 			return
 		}
+		scheme := string(myWallet.Scheme())
+		publicKeyStr := myWallet.PublicKeyStr()
 
-		value32 := float32(value)
+		value, err := block.ParseAmount(*t.Value)
+		if err != nil {
+			log.Printf("ERROR : parse value: %v", err)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+		fee, err := t.ParseFee()
+		if err != nil {
+			log.Printf("ERROR : parse fee: %v", err)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
 
 		w.Header().Add("Content-Type", "application/json")
 
-		transaction := wallet.NewTransaction(privateKey, publicKey, *t.SenderBlockchainAddress, *t.RecipientBlockchainAddress, value32)
-		signature := transaction.GenerateSignature()
-		signatureStr := signature.String()
+		nonce, err := ws.transactionNonce(&t)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		transaction := wallet.NewTransaction(myWallet.Signer(), *t.SenderBlockchainAddress, *t.RecipientBlockchainAddress, value, fee, nonce)
+		signature, err := transaction.GenerateSignature()
+		if err != nil {
+			log.Printf("ERROR: sign transaction: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+		signatureStr := hex.EncodeToString(signature)
 
 		bt := &block.TransactionRequest{
-			t.SenderBlockchainAddress,
-			t.RecipientBlockchainAddress,
-			t.SenderPublicKey,
-			&value32, &signatureStr,
+			SenderBlockchainAddress:    t.SenderBlockchainAddress,
+			RecipientBlockchainAddress: t.RecipientBlockchainAddress,
+			Scheme:                     &scheme,
+			SenderPublicKey:            &publicKeyStr,
+			Value:                      &value,
+			Fee:                        &fee,
+			Nonce:                      &nonce,
+			Signature:                  &signatureStr,
 		}
+		ws.forwardTransaction(w, bt)
 
-		m, _ := json.Marshal(bt)
-		buf := bytes.NewBuffer(m)
+		// data, _ := json.Marshal(t)
+		// fmt.Println(string(data))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ERROR: Invalid HTTP Method")
+	}
+}
 
-		resp, _ := http.Post(ws.Gateway()+"/transactions", "application/json", buf)
-		if resp.StatusCode == 201 {
-			io.WriteString(w, string(utils.JsonStatus("success")))
-			return
-		}
+// forwardTransaction mengirim transaksi bertanda tangan ke gateway dan
+// menulis hasilnya ke w.
+func (ws *WalletServer) forwardTransaction(w http.ResponseWriter, bt *block.TransactionRequest) {
+	m, _ := json.Marshal(bt)
+	buf := bytes.NewBuffer(m)
 
+	resp, err := http.Post(ws.Gateway()+"/transactions", "application/json", buf)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
 		io.WriteString(w, string(utils.JsonStatus("fail")))
 		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 201 {
+		io.WriteString(w, string(utils.JsonStatus("success")))
+		return
+	}
 
-		// data, _ := json.Marshal(t)
-		// fmt.Println(string(data))
+	// Teruskan alasan penolakan dari node (mis. saldo tidak cukup)
+	io.Copy(w, resp.Body)
+}
+
+// PrepareTransaction mengembalikan transaksi yang belum ditandatangani
+// (dengan nonce dari gateway) beserta payload kanonik dan hash-nya, agar
+// client bisa menandatangani tanpa mengirim private key.
+func (ws *WalletServer) PrepareTransaction(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodPost:
+		var t wallet.TransactionRequest
+		if err := json.NewDecoder(req.Body).Decode(&t); err != nil || !t.Validate() {
+			log.Println("ERROR : Missing fields!")
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		var schemeStr string
+		if t.Scheme != nil {
+			schemeStr = *t.Scheme
+		}
+		var publicKey utils.Verifier
+		scheme, err := utils.ParseScheme(schemeStr)
+		if err == nil {
+			publicKey, err = utils.ParsePublicKey(scheme, *t.SenderPublicKey)
+		}
+		if err != nil {
+			log.Printf("ERROR : %v", err)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+		value, err := block.ParseAmount(*t.Value)
+		if err != nil {
+			log.Printf("ERROR : parse value: %v", err)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+		fee, err := t.ParseFee()
+		if err != nil {
+			log.Printf("ERROR : parse fee: %v", err)
+			io.WriteString(w, string(utils.JsonError(err)))
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+
+		nonce, err := ws.transactionNonce(&t)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		p := wallet.NewPreparedTransaction(publicKey, *t.SenderBlockchainAddress, *t.RecipientBlockchainAddress, value, fee, nonce)
+		m, _ := json.Marshal(p)
+		io.WriteString(w, string(m[:]))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ERROR: Invalid HTTP Method")
+	}
+}
+
+// SubmitTransaction menerima transaksi yang sudah ditandatangani client
+// (format sama dengan POST /transactions di gateway) dan meneruskannya.
+func (ws *WalletServer) SubmitTransaction(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodPost:
+		var bt block.TransactionRequest
+		if err := json.NewDecoder(req.Body).Decode(&bt); err != nil || !bt.Validate() {
+			log.Println("ERROR : Missing fields!")
+			io.WriteString(w, string(utils.JsonStatus("fail")))
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		ws.forwardTransaction(w, &bt)
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 		log.Println("ERROR: Invalid HTTP Method")
 	}
 }
 
+// transactionNonce memakai nonce dari request (untuk replace-by-fee) atau
+// nonce berikutnya dari gateway.
+func (ws *WalletServer) transactionNonce(t *wallet.TransactionRequest) (uint64, error) {
+	if t.Nonce != nil {
+		return *t.Nonce, nil
+	}
+	return ws.NextNonce(*t.SenderBlockchainAddress)
+}
+
+// NextNonce menanyakan nonce berikutnya untuk address ke blockchain gateway.
+func (ws *WalletServer) NextNonce(blockchainAddress string) (uint64, error) {
+	endpoint := fmt.Sprintf("%s/nonce", ws.Gateway())
+
+	client := &http.Client{}
+	bcsReq, _ := http.NewRequest("GET", endpoint, nil)
+	q := bcsReq.URL.Query()
+	q.Add("blockchain_address", blockchainAddress)
+	bcsReq.URL.RawQuery = q.Encode()
+
+	bcsResp, err := client.Do(bcsReq)
+	if err != nil {
+		return 0, err
+	}
+	defer bcsResp.Body.Close()
+	if bcsResp.StatusCode != 200 {
+		return 0, fmt.Errorf("nonce request failed: %s", bcsResp.Status)
+	}
+
+	var nr block.NonceResponse
+	if err := json.NewDecoder(bcsResp.Body).Decode(&nr); err != nil {
+		return 0, err
+	}
+	return nr.Nonce, nil
+}
+
 func (ws *WalletServer) WalletAmount(w http.ResponseWriter, req *http.Request) {
 	switch req.Method {
 	case http.MethodGet:
@@ -153,8 +644,8 @@ func (ws *WalletServer) WalletAmount(w http.ResponseWriter, req *http.Request) {
 			}
 
 			m, _ := json.Marshal(struct {
-				Message string  `json:"message"`
-				Amount  float32 `json:"amount"`
+				Message string       `json:"message"`
+				Amount  block.Amount `json:"amount"`
 			}{
 				Message: "success",
 				Amount:  bar.Amount,
@@ -172,7 +663,12 @@ func (ws *WalletServer) WalletAmount(w http.ResponseWriter, req *http.Request) {
 func (ws *WalletServer) Run() {
 	http.HandleFunc("/", ws.Index)
 	http.HandleFunc("/wallet", ws.Wallet)
+	http.HandleFunc("/wallet/unlock", ws.WalletUnlock)
 	http.HandleFunc("/wallet/amount", ws.WalletAmount)
+	http.HandleFunc("/hd/wallet", ws.HDWallet)
+	http.HandleFunc("/hd/receive", ws.HDReceive)
 	http.HandleFunc("/transaction", ws.CreateTransaction)
+	http.HandleFunc("/transaction/prepare", ws.PrepareTransaction)
+	http.HandleFunc("/transaction/submit", ws.SubmitTransaction)
 	log.Fatal(http.ListenAndServe("0.0.0.0:"+strconv.Itoa(int(ws.port)), nil))
 }
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"learn-blockchain/block"
+	"learn-blockchain/utils"
+	"learn-blockchain/wallet"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testGateway meniru POST /transactions dan GET /nonce milik
+// blockchain_server: transaksi hanya diterima bila signature-nya valid.
+type testGateway struct {
+	mux      sync.Mutex
+	received []*block.TransactionRequest
+}
+
+func (g *testGateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/nonce":
+		m, _ := json.Marshal(&block.NonceResponse{Nonce: 0})
+		w.Write(m)
+	case "/transactions":
+		var tr block.TransactionRequest
+		if err := json.NewDecoder(req.Body).Decode(&tr); err != nil || !tr.Validate() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		publicKey, signature, err := tr.KeyAndSignature()
+		if err == nil && utils.BlockchainAddress(publicKey) != *tr.SenderBlockchainAddress {
+			err = utils.ErrSignatureMismatch
+		}
+		if err == nil {
+			t := block.NewUnsignedTransaction(publicKey, *tr.SenderBlockchainAddress, *tr.RecipientBlockchainAddress,
+				*tr.Value, tr.FeeAmount(), *tr.Nonce)
+			h := t.SigningHash()
+			err = publicKey.Verify(h[:], signature)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(utils.JsonError(err))
+			return
+		}
+		g.mux.Lock()
+		g.received = append(g.received, &tr)
+		g.mux.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (g *testGateway) count() int {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	return len(g.received)
+}
+
+func newTestWalletServer(t *testing.T) (*WalletServer, *testGateway) {
+	t.Helper()
+	gateway := &testGateway{}
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+	return NewWalletServer(0, server.URL, t.TempDir()), gateway
+}
+
+func postJSON(t *testing.T, handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	m, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(m)
+}
+
+// createTestWallet membuat wallet terenkripsi lewat /wallet.
+func createTestWallet(t *testing.T, ws *WalletServer, scheme string, passphrase string) *unlockResponse {
+	t.Helper()
+	rec := postJSON(t, ws.Wallet, mustJSON(t, map[string]string{"scheme": scheme, "passphrase": passphrase}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /wallet status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp unlockResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+func TestWalletRequiresPassphrase(t *testing.T) {
+	ws, _ := newTestWalletServer(t)
+	for _, body := range []string{``, `{}`, `{"scheme":"p256"}`, `{"passphrase":""}`} {
+		if rec := postJSON(t, ws.Wallet, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST /wallet %q status = %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	rec := postJSON(t, ws.Wallet, `{"scheme":"ed25519","passphrase":"secret"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /wallet status = %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "private_key") {
+		t.Errorf("POST /wallet returned a private key: %s", rec.Body)
+	}
+	var resp unlockResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Scheme != utils.SCHEME_ED25519 || resp.SessionToken == "" ||
+		resp.ExpiresIn != int(UNLOCK_SESSION_TTL/time.Second) {
+		t.Errorf("response = %+v, want an ed25519 wallet with a session token", resp)
+	}
+	if _, err := os.Stat(wallet.KeystorePath(ws.KeystoreDir(), resp.BlockchainAddress)); err != nil {
+		t.Errorf("keystore not written: %v", err)
+	}
+}
+
+func TestCreateTransactionAuth(t *testing.T) {
+	ws, gateway := newTestWalletServer(t)
+	sender := createTestWallet(t, ws, "secp256k1", "secret")
+	other := createTestWallet(t, ws, "p256", "other")
+
+	unlock := postJSON(t, ws.WalletUnlock, mustJSON(t, map[string]string{
+		"blockchain_address": sender.BlockchainAddress,
+		"passphrase":         "secret",
+	}))
+	var unlocked unlockResponse
+	if err := json.Unmarshal(unlock.Body.Bytes(), &unlocked); err != nil || unlocked.SessionToken == "" {
+		t.Fatalf("POST /wallet/unlock = %d %s", unlock.Code, unlock.Body)
+	}
+	if unlocked.SessionToken == sender.SessionToken {
+		t.Error("unlock returned the same session token as create")
+	}
+
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name         string
+		sessionToken *string
+		passphrase   *string
+		wantCode     int
+	}{
+		{"locked", nil, nil, http.StatusUnauthorized},
+		{"unknown token", str(strings.Repeat("00", 32)), nil, http.StatusUnauthorized},
+		{"token of another wallet", str(other.SessionToken), nil, http.StatusUnauthorized},
+		{"wrong passphrase", nil, str("wrong"), http.StatusUnauthorized},
+		{"create token", str(sender.SessionToken), nil, http.StatusOK},
+		{"unlock token", str(unlocked.SessionToken), nil, http.StatusOK},
+		{"passphrase", nil, str("secret"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := gateway.count()
+			rec := postJSON(t, ws.CreateTransaction, mustJSON(t, &wallet.TransactionRequest{
+				SenderBlockchainAddress:    str(sender.BlockchainAddress),
+				RecipientBlockchainAddress: str(other.BlockchainAddress),
+				SenderPublicKey:            str(sender.PublicKey),
+				Value:                      str("1"),
+				SessionToken:               tt.sessionToken,
+				Passphrase:                 tt.passphrase,
+			}))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("POST /transaction status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			forwarded := gateway.count() - before
+			if tt.wantCode == http.StatusOK && (forwarded != 1 || !strings.Contains(rec.Body.String(), "success")) {
+				t.Errorf("transaction not accepted by gateway: %s", rec.Body)
+			}
+			if tt.wantCode != http.StatusOK && forwarded != 0 {
+				t.Error("locked request was signed and forwarded")
+			}
+		})
+	}
+}
+
+func TestSessionExpires(t *testing.T) {
+	ws, _ := newTestWalletServer(t)
+	ws.sessionTTL = 50 * time.Millisecond
+	resp := createTestWallet(t, ws, "p256", "secret")
+	if _, ok := ws.sessionWallet(resp.SessionToken, resp.BlockchainAddress); !ok {
+		t.Fatal("fresh session is not usable")
+	}
+
+	time.Sleep(2 * ws.sessionTTL)
+	if _, ok := ws.sessionWallet(resp.SessionToken, resp.BlockchainAddress); ok {
+		t.Error("expired session is still usable")
+	}
+	ws.muxSessions.Lock()
+	defer ws.muxSessions.Unlock()
+	if len(ws.sessions) != 0 {
+		t.Errorf("%d sessions kept in memory after expiry, want 0", len(ws.sessions))
+	}
+}
+
+func TestHDReceiveSession(t *testing.T) {
+	ws, gateway := newTestWalletServer(t)
+	rec := postJSON(t, ws.HDWallet, `{"passphrase":"secret"}`)
+	var created struct {
+		WalletID string `json:"wallet_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.WalletID == "" {
+		t.Fatalf("POST /hd/wallet = %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := postJSON(t, ws.HDReceive, mustJSON(t, map[string]string{
+		"wallet_id": created.WalletID, "passphrase": "wrong",
+	})); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /hd/receive with wrong passphrase status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec = postJSON(t, ws.HDReceive, mustJSON(t, map[string]string{
+		"wallet_id": created.WalletID, "passphrase": "secret",
+	}))
+	var received struct {
+		unlockResponse
+		Index uint32 `json:"index"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &received); err != nil || received.SessionToken == "" {
+		t.Fatalf("POST /hd/receive = %d %s", rec.Code, rec.Body)
+	}
+
+	body := mustJSON(t, map[string]string{
+		"sender_blockchain_address":    received.BlockchainAddress,
+		"recipient_blockchain_address": "recipient",
+		"sender_public_key":            received.PublicKey,
+		"value":                        "1",
+	})
+	if rec := postJSON(t, ws.CreateTransaction, body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /transaction without token status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	var withToken map[string]string
+	json.Unmarshal([]byte(body), &withToken)
+	withToken["session_token"] = received.SessionToken
+	if rec := postJSON(t, ws.CreateTransaction, mustJSON(t, withToken)); gateway.count() != 1 {
+		t.Errorf("POST /transaction with hd session = %s, want it forwarded", rec.Body)
+	}
+}
+
+func TestPrepareSignSubmit(t *testing.T) {
+	for _, scheme := range []utils.Scheme{utils.SCHEME_P256, utils.SCHEME_SECP256K1, utils.SCHEME_ED25519} {
+		t.Run(string(scheme), func(t *testing.T) {
+			ws, gateway := newTestWalletServer(t)
+			w, err := wallet.NewWalletWithScheme(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request := map[string]string{
+				"scheme":                       string(scheme),
+				"sender_blockchain_address":    w.BlockchainAddress(),
+				"recipient_blockchain_address": "recipient",
+				"sender_public_key":            w.PublicKeyStr(),
+				"value":                        "1.5",
+				"fee":                          "0.01",
+			}
+			rec := postJSON(t, ws.PrepareTransaction, mustJSON(t, request))
+			var prepared wallet.PreparedTransaction
+			if err := json.Unmarshal(rec.Body.Bytes(), &prepared); err != nil || prepared.Transaction == nil {
+				t.Fatalf("POST /transaction/prepare = %s", rec.Body)
+			}
+			if got := *prepared.Transaction.Scheme; got != string(scheme) {
+				t.Errorf("prepared scheme = %s, want %s", got, scheme)
+			}
+			// Browser menandatangani payload dengan SHA-256 untuk P-256,
+			// jadi hash payload harus sama dengan signing hash
+			payload, err := hex.DecodeString(prepared.SigningPayload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h := sha256.Sum256(payload); hex.EncodeToString(h[:]) != prepared.SigningHash {
+				t.Error("signing hash is not SHA-256 of the signing payload")
+			}
+
+			if err := prepared.Sign(w.Signer()); err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			rec = postJSON(t, ws.SubmitTransaction, mustJSON(t, prepared.Transaction))
+			if !strings.Contains(rec.Body.String(), "success") || gateway.count() != 1 {
+				t.Fatalf("POST /transaction/submit = %s, want it accepted", rec.Body)
+			}
+
+			// Tanpa scheme, key diurai sebagai P-256: prepare gagal, atau
+			// payload-nya memuat key lain sehingga tidak bisa ditandatangani
+			if scheme != utils.SCHEME_P256 {
+				delete(request, "scheme")
+				rec := postJSON(t, ws.PrepareTransaction, mustJSON(t, request))
+				var wrong wallet.PreparedTransaction
+				json.Unmarshal(rec.Body.Bytes(), &wrong)
+				if wrong.Transaction != nil && wrong.Sign(w.Signer()) == nil {
+					t.Errorf("prepare without scheme produced a signable transaction: %s", rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitRejectsTamperedSignature(t *testing.T) {
+	ws, gateway := newTestWalletServer(t)
+	w, err := wallet.NewWalletWithScheme(utils.SCHEME_ED25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepared := wallet.NewPreparedTransaction(w.PublicKey(), w.BlockchainAddress(), "recipient", 1, 0, 0)
+	if err := prepared.Sign(w.Signer()); err != nil {
+		t.Fatal(err)
+	}
+	value := *prepared.Transaction.Value + 1
+	prepared.Transaction.Value = &value
+
+	rec := postJSON(t, ws.SubmitTransaction, mustJSON(t, prepared.Transaction))
+	if strings.Contains(rec.Body.String(), "success") || gateway.count() != 0 {
+		t.Errorf("POST /transaction/submit accepted a tampered transaction: %s", rec.Body)
+	}
+}